@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"crawleragent-v2/internal/config"
+	"crawleragent-v2/internal/infra/crawler"
+	"crawleragent-v2/internal/infra/crawler/session"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// runLogin 打开有界面的浏览器让用户手动登录,检测到登录成功的元素后保存会话:
+//
+//	go run ./cmd/crawler login --url https://www.zhipin.com/web/user/ --selector ".nav-figure" --out session.json
+//
+// 登录状态同时保存在实例的用户数据目录中,保存的会话文件可以通过任务文件的 session 字段注入其他实例
+func runLogin(args []string) error {
+	flags := flag.NewFlagSet("login", flag.ExitOnError)
+	loginURL := flags.String("url", "", "登录页面URL")
+	selector := flags.String("selector", "", "登录成功后才会出现的元素的CSS选择器")
+	instanceID := flags.Int("instance", 0, "浏览器实例编号,对应 user_data_dir/instance_N")
+	out := flags.String("out", "session.json", "会话文件保存路径")
+	origins := flags.String("origins", "", "额外保存 cookie 和 localStorage 的站点,多个用逗号分隔,默认只保存登录页面所在站点")
+	timeout := flags.Duration("timeout", 10*time.Minute, "等待登录的最长时间")
+	flags.Parse(args)
+
+	if *loginURL == "" || *selector == "" {
+		return fmt.Errorf("必须通过 --url 和 --selector 指定登录页面和登录成功的元素")
+	}
+	target, err := url.Parse(*loginURL)
+	if err != nil || target.Host == "" {
+		return fmt.Errorf("登录页面URL无效: %s", *loginURL)
+	}
+	originList := []string{target.Scheme + "://" + target.Host}
+	for _, origin := range strings.Split(*origins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			originList = append(originList, origin)
+		}
+	}
+
+	cfg, err := config.InitConfig()
+	if err != nil {
+		return fmt.Errorf("解析配置失败: %v", err)
+	}
+	// 登录必须有界面,并且使用实例自己的数据目录而不是系统浏览器
+	cfg.Rod.UserMode = false
+	browser, cleanup, err := crawler.LaunchInstance(cfg, *instanceID, crawler.WithHeadless(false))
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	page, err := browser.Page(proto.TargetCreateTarget{URL: *loginURL})
+	if err != nil {
+		return fmt.Errorf("打开登录页面失败: %v", err)
+	}
+
+	log.Printf("请在浏览器中完成登录, 检测到 %s 后自动保存会话 (最长等待 %v)", *selector, *timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	// 登录过程中可能发生多次跳转,Element 会一直重试直到元素出现
+	if _, err := page.Context(ctx).Element(*selector); err != nil {
+		return fmt.Errorf("等待登录超时: %v", err)
+	}
+	log.Printf("检测到登录成功")
+
+	s, err := session.Export(browser, originList)
+	if err != nil {
+		return fmt.Errorf("导出会话失败: %v", err)
+	}
+	if err := s.Save(*out); err != nil {
+		return err
+	}
+	log.Printf("已保存 %d 个cookie, %d 个站点的localStorage到 %s", len(s.Cookies), len(s.LocalStorage), *out)
+	return nil
+}
@@ -3,33 +3,70 @@ package main
 import (
 	"context"
 	"crawleragent-v2/internal/config"
-	"crawleragent-v2/internal/data/entity"
 	"crawleragent-v2/internal/data/model"
 	"crawleragent-v2/internal/infra/crawler/parallel"
 	"crawleragent-v2/internal/infra/embedding"
+	"crawleragent-v2/internal/infra/frontier"
 	"crawleragent-v2/internal/infra/persistence/es"
+	"crawleragent-v2/internal/infra/persistence/rdb"
+	"crawleragent-v2/internal/infra/queue"
+	"crawleragent-v2/internal/job"
 	"crawleragent-v2/internal/service/crawler"
 	"crawleragent-v2/param"
 	"crawleragent-v2/types"
-	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
-)
 
-var (
-	urlBoss           = "https://www.zhipin.com/web/geek/jobs?city=100010000&salary=406&experience=102&query=golang"
-	urlPatternBoss    = "https://www.zhipin.com/wapi/zpgeek/search/joblist.json*"
-	urlCnBlogs        = "https://www.cnblogs.com/"
-	urlPatternCnBlogs = "https://www.cnblogs.com/AggSite/AggSitePostList*"
-	selectorCnBlogs   = `//a[starts-with(@href, "/sitehome/p/") and text()=">"]`
-	urlBili           = "https://www.bilibili.com/"
-	urlPatternBili    = "https://api.bilibili.com/x/web-interface/index/ogv/rcmd*"
-	urlCsdn           = "https://www.csdn.net/"
-	urlPatternCsdn    = "https://cms-api.csdn.net/v1/web_home/select_content*"
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "login" {
+		if err := runLogin(os.Args[2:]); err != nil {
+			log.Fatalf("登录失败: %v", err)
+		}
+		return
+	}
+
+	jobFile := flag.String("job", "", "任务文件路径(.yaml/.yml/.json)")
+	poolSize := flag.Int("pool", 0, "浏览器池大小(page 模式下为页面数),覆盖任务文件中的 browser_pool_size")
+	excelFile := flag.String("excel", "", "爬取完成后导出boss_jobs索引到Excel文件,为空则不导出")
+	reportFile := flag.String("report", "", "爬取报告的JSON导出路径,为空则不导出")
+	queueName := flag.String("queue", "", "Redis任务队列名称,指定后 --job 中的任务会写入队列而不是直接执行")
+	worker := flag.Bool("worker", false, "作为队列worker运行,从 --queue 指定的队列中领取任务")
+	untilEmpty := flag.Bool("until-empty", false, "队列中没有待处理和处理中的任务后退出worker")
+	lease := flag.Duration("lease", time.Minute, "队列任务的租约时长,worker退出后任务在租约过期后重新入队")
+	maxAttempts := flag.Int("max-attempts", 3, "队列任务的最大尝试次数,超过后放入死信队列")
+	frontierTTL := flag.Duration("frontier-ttl", frontier.DefaultRedisDedupeTTL, "Redis去重集合在最后一次写入后的保留时间,过期后重新运行会重新爬取已发现的链接")
+	frontierReset := flag.Bool("frontier-reset", false, "运行前清空Redis去重集合,重新爬取上次运行已发现的链接")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 30*time.Second, "收到退出信号后等待执行中任务完成的最长时间")
+	flag.Parse()
+
+	if *jobFile == "" && !*worker {
+		log.Fatalf("必须通过 --job 指定任务文件")
+	}
+	if *worker && *queueName == "" {
+		log.Fatalf("worker 模式必须通过 --queue 指定队列名称")
+	}
+
+	crawlJob := &job.Job{}
+	if *jobFile != "" {
+		var err error
+		crawlJob, err = job.LoadJob(*jobFile)
+		if err != nil {
+			log.Fatalf("加载任务文件失败: %v", err)
+		}
+	}
+	if *queueName != "" && crawlJob.Frontier != nil {
+		log.Fatalf("队列模式暂不支持 frontier 链接跟随")
+	}
+
 	appcfg, err := config.InitConfig()
 	if err != nil {
 		log.Fatalf("解析配置失败: %v", err)
@@ -42,80 +79,69 @@ func main() {
 	// 当你不知道使用哪个Context，或者没有可用的Context时，可以使用它作为起点。
 	// 它永远不会被取消，没有超时时间，也没有值。
 	ctx := context.Background()
-	//运行前确保es服务启动完成
-	parallelCrawler, err := parallel.InitBrowserPoolCrawler(appcfg, 3)
-	if err != nil {
-		log.Fatalf("初始化BrowserPoolCrawler失败: %v", err)
-	}
-	defer parallelCrawler.Close()
-
-	clickXActions := make([]param.Action, 0, 5)
-	for range 5 {
-		clickXActions = append(clickXActions, &param.ClickXAction{
-			BaseParams: param.BaseParams{
-				Delay: 2000 * time.Millisecond,
-			},
-			Selector: selectorCnBlogs,
-		},
-		)
-	}
-
-	scrollActions := make([]param.Action, 0, 5)
-	for range 5 {
-		scrollActions = append(scrollActions, &param.ScrollAction{
-			BaseParams: param.BaseParams{
-				Delay: 2000 * time.Millisecond,
-			},
-			ScrollY: 1000,
-		})
-	}
 
-	jsActions := make([]param.Action, 0, 5)
-	for range 5 {
-		jsActions = append(jsActions, &param.JavaScriptAction{
-			BaseParams: param.BaseParams{
-				Delay: 2000 * time.Millisecond,
-			},
-			JavaScript: `
-					() => {
-						function getAllHrefLinks() {
-							// 选择器「[href]」匹配所有拥有href属性的元素（无论标签类型）
-							const hrefElements = document.querySelectorAll('[href]');
-							
-							const allHrefs = Array.from(hrefElements)
-								.map(element => {
-								// 按需选择：获取绝对路径 OR 原始属性值
-								const absoluteHref = element.href; // 完整URL（推荐，通用性更强）
-								const originalHref = element.getAttribute('href'); // 原始值（如 "../test.html"、"#top"）
-								return absoluteHref; // 可替换为 originalHref
-								})
-								.filter(href => !!href.trim()) // 过滤无效空链接
-								.filter((href, index, self) => self.indexOf(href) === index); // 数组去重
-							
-							return allHrefs;
-						}
-						return getAllHrefLinks();
-					}
-				`,
-			ProcessFunc: func(ctx context.Context, content types.UrlContent) error {
-				log.Printf("执行JavaScript成功:%s, %d, %s", content.GetUrl(), len(content.GetContent()), string(content.GetContent()[:100]))
-				return nil
-			},
-		})
+	var redisClient *redis.Client
+	if *queueName != "" || (crawlJob.Frontier != nil && crawlJob.Frontier.Dedupe == param.DedupeRedis) {
+		redisClient, err = rdb.InitRedisClient(ctx, appcfg)
+		if err != nil {
+			log.Fatalf("初始化Redis客户端失败: %v", err)
+		}
+		defer redisClient.Close()
 	}
 
-	scrollAndJsActions := make([]param.Action, 0, 10)
-	clickXAndJsActions := make([]param.Action, 0, 10)
+	var taskQueue queue.TaskQueue
+	if *queueName != "" {
+		taskQueue = queue.InitRedisQueue(redisClient, *queueName, *maxAttempts)
 
-	for i := range 5 {
-		scrollAndJsActions = append(scrollAndJsActions, scrollActions[i])
-		scrollAndJsActions = append(scrollAndJsActions, jsActions[i])
+		if len(crawlJob.Tasks) > 0 {
+			ids, err := taskQueue.Enqueue(ctx, crawlJob.Tasks...)
+			if err != nil {
+				log.Fatalf("任务入队失败: %v", err)
+			}
+			log.Printf("任务 %s 的 %d 个URL已写入队列 %s", crawlJob.Name, len(ids), *queueName)
+		}
+		if !*worker {
+			return
+		}
+	}
+
+	browserPoolSize := crawlJob.BrowserPoolSize
+	if *poolSize > 0 {
+		browserPoolSize = *poolSize
+	}
+	if browserPoolSize <= 0 {
+		browserPoolSize = 3
 	}
 
-	for i := range 5 {
-		clickXAndJsActions = append(clickXAndJsActions, clickXActions[i])
-		clickXAndJsActions = append(clickXAndJsActions, jsActions[i])
+	//运行前确保es服务启动完成
+	parallelCrawler, err := parallel.InitParallelCrawler(appcfg, browserPoolSize)
+	if err != nil {
+		log.Fatalf("初始化并行爬虫失败: %v", err)
 	}
+	closeCrawler := sync.OnceValue(func() error {
+		closeCtx, cancel := context.WithTimeout(ctx, *shutdownTimeout)
+		defer cancel()
+		return parallelCrawler.Close(closeCtx)
+	})
+	defer closeCrawler()
+
+	// 第一次收到 SIGINT/SIGTERM 时停止接收新任务并等待执行中的任务完成,再次收到时直接退出
+	runCtx, cancelRun := context.WithCancel(ctx)
+	defer cancelRun()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-sigCh
+		signal.Stop(sigCh)
+		log.Printf("收到信号 %v, 停止接收新任务, 最长等待 %v, 再次发送信号强制退出", sig, *shutdownTimeout)
+		cancelRun()
+		// 队列模式下 worker 停止领取任务,等待 ShutdownTimeout 后取消剩余任务并退出,由 main 关闭浏览器池
+		if taskQueue == nil {
+			if err := closeCrawler(); err != nil {
+				log.Printf("关闭浏览器池失败: %v", err)
+			}
+		}
+	}()
 
 	typedClient, err := es.InitTypedEsClient(appcfg, 10)
 	if err != nil {
@@ -129,92 +155,61 @@ func main() {
 
 	crawlerService := service.InitCrawlerService(parallelCrawler, embedder, typedClient, 5)
 
-	processFuncBoss := func(ctx context.Context, content types.UrlContent) error {
-		var jsonData struct {
-			Code    int    `json:"code"`
-			Message string `json:"message"`
-			ZpData  struct {
-				HasMore    bool                    `json:"hasMore"`
-				JobResList []entity.RowBossJobData `json:"jobList"`
-			} `json:"zpData"`
+	registry := job.InitProcessorRegistry()
+	if err := service.RegisterProcessors(registry, crawlerService); err != nil {
+		log.Fatalf("注册处理器失败: %v", err)
+	}
+	var urlFrontier frontier.Frontier
+	if crawlJob.Frontier != nil {
+		deduper := frontier.InitMemoryDeduper()
+		if crawlJob.Frontier.Dedupe == param.DedupeRedis {
+			if *frontierReset {
+				if err := frontier.ResetRedisDeduper(ctx, redisClient, crawlJob.Name); err != nil {
+					log.Fatalf("%v", err)
+				}
+			}
+			deduper = frontier.InitRedisDeduper(redisClient, crawlJob.Name, *frontierTTL)
 		}
-
-		if err := json.Unmarshal(content.GetContent(), &jsonData); err != nil {
-			return fmt.Errorf("JSON解析失败: %v", err)
+		urlFrontier, err = frontier.InitFrontier(crawlJob.Frontier, deduper)
+		if err != nil {
+			log.Fatalf("初始化frontier失败: %v", err)
 		}
-
-		if jsonData.Code != 0 {
-			return fmt.Errorf("API返回错误: %d - %s", jsonData.Code, jsonData.Message)
+		if err := registry.Register("frontier", urlFrontier.Process); err != nil {
+			log.Fatalf("注册处理器失败: %v", err)
 		}
+	}
+	if err := crawlJob.Resolve(registry); err != nil {
+		log.Fatalf("解析任务处理器失败: %v", err)
+	}
 
-		results := make([]model.Document, 0, len(jsonData.ZpData.JobResList))
-		for _, job := range jsonData.ZpData.JobResList {
-			rowData := &entity.RowBossJobData{
-				EncryptJobId:     job.EncryptJobId,
-				SecurityId:       job.SecurityId,
-				JobName:          job.JobName,
-				SalaryDesc:       job.SalaryDesc,
-				BrandName:        job.BrandName,
-				BrandScaleName:   job.BrandScaleName,
-				CityName:         job.CityName,
-				AreaDistrict:     job.AreaDistrict,
-				BusinessDistrict: job.BusinessDistrict,
-				JobLabels:        job.JobLabels,
-				Skills:           job.Skills,
-				JobExperience:    job.JobExperience,
-				JobDegree:        job.JobDegree,
-				WelfareList:      job.WelfareList,
-			}
-			doc := rowData.ToDocument()
-			results = append(results, doc)
+	var reports []*types.CrawlReport
+	if taskQueue != nil {
+		log.Printf("开始消费队列 %s", *queueName)
+		reports, err = crawlerService.ConsumeQueue(runCtx, taskQueue, registry, service.QueueWorkerOptions{
+			Workers:         browserPoolSize,
+			Lease:           *lease,
+			ExitWhenEmpty:   *untilEmpty,
+			ShutdownTimeout: *shutdownTimeout,
+		})
+	} else if urlFrontier != nil {
+		log.Printf("开始执行任务 %s, 共 %d 个种子URL, 最大深度 %d", crawlJob.Name, len(crawlJob.Tasks), crawlJob.Frontier.MaxDepth)
+		reports, err = crawlerService.CrawlWithFrontier(ctx, crawlJob.Tasks, urlFrontier)
+	} else {
+		log.Printf("开始执行任务 %s, 共 %d 个URL", crawlJob.Name, len(crawlJob.Tasks))
+		reports, err = crawlerService.StartCrawling(ctx, crawlJob.Tasks)
+	}
+	for _, report := range reports {
+		log.Printf("任务 %s: %s, worker %d, 耗时 %v, 响应 %v, 处理错误 %d 个",
+			report.URL, report.Status, report.WorkerID, report.Duration(), report.Responses, len(report.ProcessErrors))
+	}
+	if *reportFile != "" {
+		if exportErr := exportReports(*reportFile, reports); exportErr != nil {
+			log.Printf("导出爬取报告失败: %v", exportErr)
 		}
-
-		crawlerService.EmbeddingAndIndexDocs(ctx, results)
-
-		return nil
-	}
-
-	params := []*param.ParallelCrawlerParam{
-		{
-			URL: urlBoss,
-			NetworkConfigs: []*param.ParallelNetworkConfig{
-				{
-					URLPattern:  urlPatternBoss,
-					ProcessFunc: processFuncBoss,
-				},
-			},
-			Actions: scrollAndJsActions,
-		},
-
-		{
-			URL: urlBili,
-			NetworkConfigs: []*param.ParallelNetworkConfig{
-				{
-					URLPattern: urlPatternBili,
-				},
-			},
-			Actions: scrollAndJsActions,
-		},
-		{
-			URL: urlCnBlogs,
-			NetworkConfigs: []*param.ParallelNetworkConfig{
-				{
-					URLPattern: urlPatternCnBlogs,
-				},
-			},
-			Actions: clickXAndJsActions,
-		},
-		{
-			URL: urlCsdn,
-			NetworkConfigs: []*param.ParallelNetworkConfig{
-				{
-					URLPattern: urlPatternCsdn,
-				},
-			},
-			Actions: scrollAndJsActions,
-		},
-	}
-	err = crawlerService.StartCrawling(ctx, params)
+	}
+	if closeErr := closeCrawler(); closeErr != nil {
+		log.Printf("关闭浏览器池失败: %v", closeErr)
+	}
 	if err != nil {
 		log.Fatalf("启动爬虫失败: %v", err)
 	}
@@ -226,10 +221,21 @@ func main() {
 	//打印索引中的文档数量
 	fmt.Printf("索引中的文档数量: %d\n", count)
 
-	err = typedClient.ToExcel(ctx, "C:/Users/15325/Desktop/boss_jobs.xlsx", (&model.BossJobDoc{}).GetIndex(), []string{"salaryDesc"}, 1000)
-	if err != nil {
-		log.Fatalf("导出索引文档到Excel失败: %v", err)
+	if *excelFile != "" {
+		err = typedClient.ToExcel(ctx, *excelFile, (&model.BossJobDoc{}).GetIndex(), []string{"salaryDesc"}, 1000)
+		if err != nil {
+			log.Fatalf("导出索引文档到Excel失败: %v", err)
+		}
 	}
 
 	log.Println("所有任务完成")
 }
+
+func exportReports(path string, reports []*types.CrawlReport) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return types.WriteCrawlReports(file, reports)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"crawleragent-v2/internal/config"
+	"crawleragent-v2/internal/infra/crawler"
+	"crawleragent-v2/internal/infra/crawler/session"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// 会话导入导出:
+//
+//	go run ./cmd/session export --instance 0 --origins https://www.zhipin.com --out session.json
+//	go run ./cmd/session import --instance 1 --in session.json
+//
+// 运行前需要关闭使用同一实例数据目录的爬虫,否则浏览器无法打开该用户数据目录
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println("用法: session export|import [参数]")
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	instanceID := flags.Int("instance", 0, "浏览器实例编号,对应 user_data_dir/instance_N")
+	origins := flags.String("origins", "", "导出时要保存 cookie 和 localStorage 的站点,多个用逗号分隔")
+	out := flags.String("out", "session.json", "导出的会话文件路径")
+	in := flags.String("in", "session.json", "导入的会话文件路径")
+	flags.Parse(os.Args[2:])
+
+	cfg, err := config.InitConfig()
+	if err != nil {
+		log.Fatalf("解析配置失败: %v", err)
+	}
+
+	browser, cleanup, err := crawler.LaunchInstance(cfg, *instanceID)
+	if err != nil {
+		log.Fatalf("启动浏览器实例 %d 失败: %v", *instanceID, err)
+	}
+	defer cleanup()
+
+	switch os.Args[1] {
+	case "export":
+		var originList []string
+		for _, origin := range strings.Split(*origins, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				originList = append(originList, origin)
+			}
+		}
+		s, err := session.Export(browser, originList)
+		if err != nil {
+			log.Fatalf("导出会话失败: %v", err)
+		}
+		if err := s.Save(*out); err != nil {
+			log.Fatalf("保存会话失败: %v", err)
+		}
+		log.Printf("已导出 %d 个cookie, %d 个站点的localStorage到 %s", len(s.Cookies), len(s.LocalStorage), *out)
+	case "import":
+		s, err := session.LoadSession(*in)
+		if err != nil {
+			log.Fatalf("加载会话失败: %v", err)
+		}
+		if err := session.Apply(browser, s); err != nil {
+			log.Fatalf("导入会话失败: %v", err)
+		}
+		log.Printf("已将 %s 导入浏览器实例 %d", *in, *instanceID)
+	default:
+		log.Fatalf("未知子命令: %s", os.Args[1])
+	}
+}
@@ -15,6 +15,26 @@ func InitConfig() (*Config, error) {
 	viper.SetDefault("elasticsearch.host", "http://localhost")
 	viper.SetDefault("elasticsearch.port", 9200)
 
+	// 设置默认值
+	viper.SetDefault("rod.pool_mode", "browser")
+	viper.SetDefault("rod.health_check_interval", "30s")
+
+	// 设置默认值
+	viper.SetDefault("robots.enabled", false)
+	viper.SetDefault("robots.user_agent", "crawleragent")
+	viper.SetDefault("robots.cache_ttl", "24h")
+
+	// 设置默认值
+	viper.SetDefault("challenge.enabled", false)
+	viper.SetDefault("challenge.timeout", "5m")
+
+	// 设置默认值
+	viper.SetDefault("artifacts.dir", "artifacts")
+
+	// 设置默认值
+	viper.SetDefault("redis.addr", "localhost:6379")
+	viper.SetDefault("redis.db", 0)
+
 	// 设置默认值
 	viper.SetDefault("embedding.host", "http://localhost")
 	viper.SetDefault("embedding.port", 11434)
@@ -0,0 +1,33 @@
+package action
+
+import (
+	"context"
+	"crawleragent-v2/param"
+	"crawleragent-v2/types"
+
+	"github.com/go-rod/rod"
+)
+
+// Executor 是AI爬虫与并行爬虫共用的操作执行器
+// 新的操作类型只需在 param 中注册,并在 rodExecutor.perform 中实现
+// 返回的结果按执行顺序包含每个已执行的操作(含子操作),出错时也会返回已执行部分的结果
+type Executor interface {
+	ExecuteActions(ctx context.Context, page *rod.Page, actions []param.Action, opts *ExecuteOptions) ([]types.ActionOutcome, error)
+}
+
+// ExecuteOptions 是一次执行的附加参数
+type ExecuteOptions struct {
+	// WaitIncludes 和 WaitExcludes 是操作后等待网络空闲时关注的URL模式,与监听器的模式格式一致
+	WaitIncludes []string
+	WaitExcludes []string
+	// Responses 是监听器记录的响应,RepeatAction 的 until_false 条件依赖它
+	Responses ResponseRecorder
+	// AfterAction 在每个非控制流操作的结果确定后调用,用于截图和反爬验证检测,返回错误时终止执行
+	AfterAction func(outcome types.ActionOutcome) error
+	// BeforeNavigate 在 navigate 操作导航前,以及点击操作导致页面地址改变后调用,
+	// 用于 robots.txt 检查和按host限流,返回错误时操作失败
+	// navigate 传入目标URL; click、click_x、scroll 和 auto_scroll 的每一轮传入当前页面的URL
+	BeforeNavigate func(ctx context.Context, url string) error
+	// Humanize 为 true 时模拟真实用户操作: 鼠标沿曲线移动后点击,滚轮随机步长滚动,逐字输入,操作后的延迟随机浮动
+	Humanize bool
+}
@@ -0,0 +1,182 @@
+package action
+
+import (
+	"context"
+	"crawleragent-v2/param"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+const (
+	// defaultAutoScrollQuietPeriod 滚动后在该时长内没有新内容即停止
+	defaultAutoScrollQuietPeriod = 3 * time.Second
+	// defaultAutoScrollMaxRounds 自动滚动未指定轮数上限时的最大轮数
+	defaultAutoScrollMaxRounds = 50
+	// defaultAutoScrollMaxTime 自动滚动未指定时长上限时的最长时间
+	defaultAutoScrollMaxTime = 2 * time.Minute
+	// autoScrollPollInterval 等待新内容时检查页面高度和响应数量的间隔
+	autoScrollPollInterval = 200 * time.Millisecond
+	// autoScrollTimeoutMargin 超时时间短于时长上限时,在超时前留出的结束滚动的时间
+	autoScrollTimeoutMargin = time.Second
+)
+
+// 自动滚动的停止原因,记录在 ActionOutcome.StopReason 中
+const (
+	stopNoGrowth  = "no_growth"
+	stopMaxRounds = "max_rounds"
+	stopMaxItems  = "max_items"
+	stopMaxTime   = "max_time"
+)
+
+const scrollMetricsJS = `() => ({
+	height: document.documentElement.scrollHeight,
+	remaining: document.documentElement.scrollHeight - window.scrollY - window.innerHeight,
+})`
+
+const scrollToBottomJS = `() => window.scrollTo(0, document.documentElement.scrollHeight)`
+
+const countElementsJS = `(selector) => document.querySelectorAll(selector).length`
+
+type scrollMetrics struct {
+	Height    float64 `json:"height"`
+	Remaining float64 `json:"remaining"`
+}
+
+// autoScroll 反复滚动到页面底部,直到没有新内容或达到上限,达到上限视为成功
+// 实际执行的滚动轮数和停止原因分别记录在当前操作的结果中
+func (e *rodExecutor) autoScroll(ctx context.Context, exec *execution, page *rod.Page, action *param.AutoScrollAction) error {
+	quietPeriod := action.QuietPeriod
+	if quietPeriod <= 0 {
+		quietPeriod = defaultAutoScrollQuietPeriod
+	}
+	maxRounds := action.MaxRounds
+	if maxRounds <= 0 {
+		maxRounds = defaultAutoScrollMaxRounds
+	}
+	maxTime := action.MaxTime
+	if maxTime <= 0 {
+		maxTime = defaultAutoScrollMaxTime
+	}
+	// 单次尝试的超时时间比时长上限先到时以超时时间为准,提前结束视为达到时长上限,避免操作失败后重试
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline) - autoScrollTimeoutMargin; remaining < maxTime {
+			maxTime = max(remaining, 0)
+		}
+	}
+	patterns := exec.opts.WaitIncludes
+	if action.URLPattern != "" {
+		patterns = []string{action.URLPattern}
+	}
+
+	budget, cancel := context.WithTimeout(ctx, maxTime)
+	defer cancel()
+	page = page.Context(budget)
+
+	rounds := 0
+	var reason string
+	for reason == "" {
+		var err error
+		if reason, err = autoScrollLimit(page, action, rounds, maxRounds); err == nil && reason == "" {
+			var scrolled bool
+			scrolled, reason, err = e.autoScrollRound(budget, exec, page, patterns, quietPeriod)
+			// 已经滚动的轮次都计入轮数,包括没有新内容和等待中达到时长上限的最后一轮
+			if scrolled {
+				rounds++
+			}
+		}
+		if err != nil {
+			if budget.Err() == nil || ctx.Err() != nil {
+				return err
+			}
+			reason = stopMaxTime
+		}
+	}
+
+	outcome := &(*exec.outcomes)[exec.current]
+	outcome.Rounds = rounds
+	outcome.StopReason = reason
+	log.Printf("自动滚动停止: %s, 共滚动 %d 轮", reason, rounds)
+	return nil
+}
+
+// autoScrollLimit 在每轮滚动前检查轮数和条目数量上限,返回非空的停止原因表示不再滚动
+func autoScrollLimit(page *rod.Page, action *param.AutoScrollAction, rounds, maxRounds int) (string, error) {
+	if rounds >= maxRounds {
+		return stopMaxRounds, nil
+	}
+	if action.MaxItems > 0 {
+		result, err := page.Eval(countElementsJS, action.ItemSelector)
+		if err != nil {
+			return "", fmt.Errorf("统计条目数量失败: %v", err)
+		}
+		if result.Value.Int() >= action.MaxItems {
+			return stopMaxItems, nil
+		}
+	}
+	return "", nil
+}
+
+// autoScrollRound 执行一轮滚动并等待新内容, scrolled 表示已经滚动,
+// 返回非空的停止原因表示没有加载新内容,不再继续
+func (e *rodExecutor) autoScrollRound(ctx context.Context, exec *execution, page *rod.Page,
+	patterns []string, quietPeriod time.Duration) (scrolled bool, reason string, err error) {
+	before, err := getScrollMetrics(page)
+	if err != nil {
+		return false, "", err
+	}
+	responses := countResponses(exec.opts.Responses, patterns)
+	if exec.opts.Humanize {
+		err = humanScroll(ctx, page, before.Remaining)
+	} else {
+		_, err = page.Eval(scrollToBottomJS)
+	}
+	if err != nil {
+		return false, "", fmt.Errorf("滚动到底部失败: %v", err)
+	}
+
+	// 页面高度增长或收到新的响应都视为加载了新内容,立即进入下一轮
+	deadline := time.Now().Add(quietPeriod)
+	for time.Now().Before(deadline) {
+		if err := sleep(ctx, autoScrollPollInterval); err != nil {
+			return true, "", err
+		}
+		if countResponses(exec.opts.Responses, patterns) > responses {
+			return true, "", nil
+		}
+		after, err := getScrollMetrics(page)
+		if err != nil {
+			return true, "", err
+		}
+		if after.Height > before.Height {
+			return true, "", nil
+		}
+	}
+	return true, stopNoGrowth, nil
+}
+
+func getScrollMetrics(page *rod.Page) (*scrollMetrics, error) {
+	result, err := page.Eval(scrollMetricsJS)
+	if err != nil {
+		return nil, fmt.Errorf("获取页面高度失败: %v", err)
+	}
+	metrics := &scrollMetrics{}
+	if err := result.Value.Unmarshal(metrics); err != nil {
+		return nil, fmt.Errorf("解析页面高度失败: %v", err)
+	}
+	return metrics, nil
+}
+
+// countResponses 返回各模式已捕获响应数量之和,未监听网络响应时为 0
+func countResponses(recorder ResponseRecorder, patterns []string) int {
+	if recorder == nil {
+		return 0
+	}
+	total := 0
+	for _, pattern := range patterns {
+		total += recorder.Count(pattern)
+	}
+	return total
+}
@@ -0,0 +1,226 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/input"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// 拟人化操作的参数,取值参考真实用户的操作节奏
+const (
+	// mouseMoveSteps 鼠标沿曲线移动的步数范围
+	minMouseMoveSteps = 15
+	maxMouseMoveSteps = 35
+	// mouseStepInterval 鼠标每步移动的间隔范围
+	minMouseStepInterval = 5 * time.Millisecond
+	maxMouseStepInterval = 20 * time.Millisecond
+	// clickHold 按下和松开鼠标之间的间隔范围
+	minClickHold = 50 * time.Millisecond
+	maxClickHold = 150 * time.Millisecond
+	// scrollStep 每次滚轮滚动的距离范围(像素)
+	minScrollStep = 80
+	maxScrollStep = 240
+	// scrollPause 两次滚轮之间的停顿范围
+	minScrollPause = 30 * time.Millisecond
+	maxScrollPause = 120 * time.Millisecond
+	// inertiaSteps 滚动结束时惯性减速的步数
+	inertiaSteps = 4
+	// keystrokeInterval 两次按键之间的间隔范围
+	minKeystrokeInterval = 50 * time.Millisecond
+	maxKeystrokeInterval = 200 * time.Millisecond
+	// delayJitter 操作后延迟的浮动比例,实际延迟在 Delay 的 [1-delayJitter, 1+delayJitter] 倍之间
+	delayJitter = 0.3
+)
+
+// 输入完成后触发 change 事件,与 element.Input 的行为保持一致
+const dispatchChangeJS = `function() {
+	this.dispatchEvent(new Event('change', { bubbles: true }));
+}`
+
+// humanClick 将鼠标沿曲线移动到元素内的随机位置后点击
+func humanClick(ctx context.Context, page *rod.Page, element *rod.Element) error {
+	if err := humanHover(ctx, page, element); err != nil {
+		return err
+	}
+	if err := page.Mouse.Down(proto.InputMouseButtonLeft, 1); err != nil {
+		return err
+	}
+	if err := sleep(ctx, randomDuration(minClickHold, maxClickHold)); err != nil {
+		return err
+	}
+	return page.Mouse.Up(proto.InputMouseButtonLeft, 1)
+}
+
+// humanHover 将元素滚动到可见区域,再将鼠标沿曲线移动到元素内的随机位置
+func humanHover(ctx context.Context, page *rod.Page, element *rod.Element) error {
+	if err := element.ScrollIntoView(); err != nil {
+		return err
+	}
+	shape, err := element.Shape()
+	if err != nil {
+		return err
+	}
+	box := shape.Box()
+	if box == nil {
+		return fmt.Errorf("元素没有可见区域")
+	}
+	// 避开边缘,落点在元素中间 60% 的区域内
+	target := proto.Point{
+		X: box.X + box.Width*(0.2+0.6*rand.Float64()),
+		Y: box.Y + box.Height*(0.2+0.6*rand.Float64()),
+	}
+	return moveMouse(ctx, page, target)
+}
+
+// moveMouse 沿三次贝塞尔曲线将鼠标从当前位置移动到 target,控制点随机偏离直线,速度先快后慢
+func moveMouse(ctx context.Context, page *rod.Page, target proto.Point) error {
+	start := page.Mouse.Position()
+	dx, dy := target.X-start.X, target.Y-start.Y
+	distance := math.Hypot(dx, dy)
+	if distance < 1 {
+		return nil
+	}
+	// 控制点沿垂直于直线的方向偏移,偏移量与距离成正比
+	nx, ny := -dy/distance, dx/distance
+	offset := func() float64 { return (rand.Float64() - 0.5) * distance * 0.5 }
+	c1 := proto.Point{X: start.X + dx*0.3 + nx*offset(), Y: start.Y + dy*0.3 + ny*offset()}
+	c2 := proto.Point{X: start.X + dx*0.7 + nx*offset(), Y: start.Y + dy*0.7 + ny*offset()}
+
+	steps := minMouseMoveSteps + rand.IntN(maxMouseMoveSteps-minMouseMoveSteps+1)
+	for i := 1; i <= steps; i++ {
+		t := easeOut(float64(i) / float64(steps))
+		point := bezier(start, c1, c2, target, t)
+		if err := page.Mouse.MoveTo(point); err != nil {
+			return err
+		}
+		if err := sleep(ctx, randomDuration(minMouseStepInterval, maxMouseStepInterval)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// humanScroll 用随机步长的滚轮事件滚动 scrollY 像素,最后几步逐渐减速模拟惯性
+func humanScroll(ctx context.Context, page *rod.Page, scrollY float64) error {
+	direction := 1.0
+	if scrollY < 0 {
+		direction = -1
+	}
+	remaining := math.Abs(scrollY)
+	for remaining > 0 {
+		step := float64(minScrollStep + rand.IntN(maxScrollStep-minScrollStep+1))
+		if remaining <= step*inertiaSteps {
+			break
+		}
+		if err := page.Mouse.Scroll(0, direction*step, 1); err != nil {
+			return err
+		}
+		remaining -= step
+		if err := sleep(ctx, randomDuration(minScrollPause, maxScrollPause)); err != nil {
+			return err
+		}
+	}
+	// 惯性阶段: 剩余距离按递减的比例分配,停顿逐渐变长
+	weights := make([]float64, inertiaSteps)
+	total := 0.0
+	for i := range weights {
+		weights[i] = float64(inertiaSteps - i)
+		total += weights[i]
+	}
+	for i, weight := range weights {
+		step := remaining * weight / total
+		if step < 1 {
+			continue
+		}
+		if err := page.Mouse.Scroll(0, direction*step, 1); err != nil {
+			return err
+		}
+		pause := randomDuration(minScrollPause, maxScrollPause) * time.Duration(i+1)
+		if err := sleep(ctx, pause); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// humanType 与 element.Input 一样聚焦元素并等待元素可编辑,再逐字按键输入,每次按键之间随机停顿
+func humanType(ctx context.Context, page *rod.Page, element *rod.Element, text string) error {
+	if err := element.Focus(); err != nil {
+		return err
+	}
+	if err := element.WaitEnabled(); err != nil {
+		return err
+	}
+	if err := element.WaitWritable(); err != nil {
+		return err
+	}
+	for i, r := range []rune(text) {
+		if i > 0 {
+			if err := sleep(ctx, randomDuration(minKeystrokeInterval, maxKeystrokeInterval)); err != nil {
+				return err
+			}
+		}
+		if err := typeRune(page, r); err != nil {
+			return err
+		}
+	}
+	_, err := element.Eval(dispatchChangeJS)
+	return err
+}
+
+// typeRune 按下并松开一个字符对应的键,页面会收到 keydown、keypress、input 和 keyup 事件
+// 键盘上没有的字符(如中文)直接以文本发送按键事件,不带键码
+func typeRune(page *rod.Page, r rune) error {
+	switch {
+	case r == '\n':
+		return page.Keyboard.Type(input.Enter)
+	case r >= ' ' && r <= '~':
+		return page.Keyboard.Type(input.Key(r))
+	}
+	text := string(r)
+	err := proto.InputDispatchKeyEvent{
+		Type:           proto.InputDispatchKeyEventTypeKeyDown,
+		Key:            text,
+		Text:           text,
+		UnmodifiedText: text,
+	}.Call(page)
+	if err != nil {
+		return err
+	}
+	return proto.InputDispatchKeyEvent{
+		Type: proto.InputDispatchKeyEventTypeKeyUp,
+		Key:  text,
+	}.Call(page)
+}
+
+// jitter 在 d 的基础上随机浮动 delayJitter 比例
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+	factor := 1 - delayJitter + 2*delayJitter*rand.Float64()
+	return time.Duration(float64(d) * factor)
+}
+
+func randomDuration(min, max time.Duration) time.Duration {
+	return min + rand.N(max-min+1)
+}
+
+func easeOut(t float64) float64 {
+	return 1 - math.Pow(1-t, 3)
+}
+
+func bezier(p0, p1, p2, p3 proto.Point, t float64) proto.Point {
+	u := 1 - t
+	a, b, c, d := u*u*u, 3*u*u*t, 3*u*t*t, t*t*t
+	return proto.Point{
+		X: a*p0.X + b*p1.X + c*p2.X + d*p3.X,
+		Y: a*p0.Y + b*p1.Y + c*p2.Y + d*p3.Y,
+	}
+}
@@ -0,0 +1,76 @@
+package action
+
+import (
+	"crawleragent-v2/types"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ResponseRecorder 记录监听器捕获到的网络响应,按监听时使用的 URL 模式归类
+// 爬虫在监听器中调用 Record,执行器通过它读取最新响应,用于控制流操作的停止条件
+type ResponseRecorder interface {
+	Record(resp *types.NetworkResponse)
+	Latest(urlPattern string) *types.NetworkResponse
+	Count(urlPattern string) int
+}
+
+type responseRecorder struct {
+	mu     sync.RWMutex
+	latest map[string]*types.NetworkResponse
+	counts map[string]int
+}
+
+func InitResponseRecorder() ResponseRecorder {
+	return &responseRecorder{
+		latest: make(map[string]*types.NetworkResponse),
+		counts: make(map[string]int),
+	}
+}
+
+func (r *responseRecorder) Record(resp *types.NetworkResponse) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latest[resp.UrlPattern] = resp
+	r.counts[resp.UrlPattern]++
+}
+
+func (r *responseRecorder) Latest(urlPattern string) *types.NetworkResponse {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.latest[urlPattern]
+}
+
+func (r *responseRecorder) Count(urlPattern string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.counts[urlPattern]
+}
+
+// lookupJSONPath 按点分隔的路径读取JSON字段,数组下标写作数字
+func lookupJSONPath(body []byte, path string) (any, bool) {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return nil, false
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			next, ok := v[key]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			value = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
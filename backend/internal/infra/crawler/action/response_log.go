@@ -0,0 +1,78 @@
+package action
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+type responseEntry struct {
+	url string
+	at  time.Time
+}
+
+// responseLog 记录执行期间页面收到的响应,供 WaitForNetworkAction 查询
+type responseLog struct {
+	mu      sync.Mutex
+	entries []responseEntry
+	// notify 在每次记录新响应时关闭并替换,用于唤醒等待者
+	notify chan struct{}
+}
+
+// watchResponses 监听页面的响应事件,ctx 取消后停止监听
+func watchResponses(ctx context.Context, page *rod.Page) *responseLog {
+	l := &responseLog{notify: make(chan struct{})}
+	wait := page.Context(ctx).EachEvent(func(e *proto.NetworkResponseReceived) {
+		l.add(e.Response.URL)
+	})
+	go wait()
+	return l
+}
+
+func (l *responseLog) add(url string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, responseEntry{url: url, at: time.Now()})
+	close(l.notify)
+	l.notify = make(chan struct{})
+}
+
+// discardBefore 丢弃 before 之前的响应,等待只会从最近一次的 prevStart 开始匹配,更早的响应不再需要
+func (l *responseLog) discardBefore(before time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// 响应按记录顺序追加,时间递增
+	i := 0
+	for i < len(l.entries) && l.entries[i].at.Before(before) {
+		i++
+	}
+	if i == 0 {
+		return
+	}
+	l.entries = append(l.entries[:0:0], l.entries[i:]...)
+}
+
+// wait 等待 since 之后出现URL匹配 reg 的响应
+func (l *responseLog) wait(ctx context.Context, reg *regexp.Regexp, since time.Time) error {
+	for {
+		l.mu.Lock()
+		for _, entry := range l.entries {
+			if !entry.at.Before(since) && reg.MatchString(entry.url) {
+				l.mu.Unlock()
+				return nil
+			}
+		}
+		notify := l.notify
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-notify:
+		}
+	}
+}
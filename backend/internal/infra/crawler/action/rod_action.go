@@ -0,0 +1,636 @@
+package action
+
+import (
+	"context"
+	"crawleragent-v2/param"
+	"crawleragent-v2/types"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/input"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+const (
+	// requestIdleDuration 无请求持续该时长即视为网络空闲
+	requestIdleDuration = 500 * time.Millisecond
+	// requestIdleTimeout 等待网络空闲的最长时间,避免轮询请求导致一直等待
+	requestIdleTimeout = 10 * time.Second
+	// defaultActionTimeout 操作未指定超时时间时单次尝试的最长时间
+	defaultActionTimeout = time.Minute
+	// defaultRetryBackoff 指定了重试但未指定退避时间时使用的默认值
+	defaultRetryBackoff = time.Second
+	// maxRetryBackoff 退避时间的上限,避免重试次数较多时等待时间溢出或过长
+	maxRetryBackoff = 5 * time.Minute
+	// maxRetryBackoffShift 退避时间最多翻倍的次数
+	maxRetryBackoffShift = 30
+	// defaultMaxRepeat RepeatAction 只指定停止条件时的最大轮数
+	defaultMaxRepeat = 100
+)
+
+// 判断元素可见性的脚本,元素不存在视为不可见
+// this 为元素时在元素内部查找,选择器 :scope 表示元素本身,否则在整个文档中查找
+const visibleJS = `
+	function (selector, visible) {
+		const root = this instanceof Element ? this : document;
+		const el = root !== document && selector === ':scope' ? root : root.querySelector(selector);
+		if (!el) {
+			return !visible;
+		}
+		const style = window.getComputedStyle(el);
+		const rect = el.getBoundingClientRect();
+		const isVisible = style.display !== 'none' && style.visibility !== 'hidden' &&
+			rect.width > 0 && rect.height > 0;
+		return isVisible === visible;
+	}
+`
+
+// baseParamsProvider 由嵌入了 param.BaseParams 的操作实现
+type baseParamsProvider interface {
+	GetBaseParams() *param.BaseParams
+}
+
+type rodExecutor struct{}
+
+func InitExecutor() Executor {
+	return &rodExecutor{}
+}
+
+// execution 保存一次 ExecuteActions 调用的状态
+type execution struct {
+	page *rod.Page
+	// scope 是子操作查找元素的范围,ForEachElementAction 中为当前元素,其余情况为页面
+	scope     scope
+	opts      *ExecuteOptions
+	responses *responseLog
+	// prevStart 是上一个操作开始执行的时间,WaitForNetworkAction 从该时间点起匹配响应
+	// 与 outcomes 一样由子执行共享,子操作更新后父操作中的后续操作能看到
+	prevStart *time.Time
+	// outcomes 按执行顺序记录每个操作(含子操作)的结果,子执行共享同一个切片
+	outcomes *[]types.ActionOutcome
+	// current 是正在执行的操作在 outcomes 中的下标,供需要记录额外结果的操作使用
+	current int
+}
+
+func (e *rodExecutor) ExecuteActions(ctx context.Context, page *rod.Page, actions []param.Action, opts *ExecuteOptions) ([]types.ActionOutcome, error) {
+	if opts == nil {
+		opts = &ExecuteOptions{}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	outcomes := make([]types.ActionOutcome, 0, len(actions))
+	prevStart := time.Now()
+	exec := &execution{
+		page:      page,
+		scope:     page,
+		opts:      opts,
+		responses: watchResponses(ctx, page),
+		prevStart: &prevStart,
+		outcomes:  &outcomes,
+	}
+	err := e.runActions(ctx, exec, "", actions)
+	return outcomes, err
+}
+
+// runActions 依次执行操作,parent 是父操作的路径,用于生成子操作的 Path
+func (e *rodExecutor) runActions(ctx context.Context, exec *execution, parent string, actions []param.Action) error {
+	for i, action := range actions {
+		index := fmt.Sprintf("actions[%d]", i)
+		path := index
+		if parent != "" {
+			path = parent + "." + index
+		}
+		if err := e.executeAction(ctx, exec, path, action); err != nil {
+			return fmt.Errorf("%s: %w", index, err)
+		}
+	}
+	return nil
+}
+
+// executeAction 按 BaseParams 执行单个操作: 每次尝试受 Timeout 限制,失败后按 RetryBackoff 退避重试,
+// Optional 操作最终失败时只记录结果,不返回错误
+func (e *rodExecutor) executeAction(ctx context.Context, exec *execution, path string, action param.Action) error {
+	if action == nil {
+		return fmt.Errorf("操作不能为空")
+	}
+	err := action.Validate()
+	if err != nil {
+		return fmt.Errorf("操作验证失败: %v", err)
+	}
+
+	base := &param.BaseParams{}
+	if provider, ok := action.(baseParamsProvider); ok {
+		base = provider.GetBaseParams()
+	}
+	// AI爬虫和队列中的任务不经过 ActionList.Validate,在这里再次校验通用参数
+	if err := base.ValidateBase(); err != nil {
+		return fmt.Errorf("操作验证失败: %v", err)
+	}
+	typeName, _ := param.ActionType(action)
+
+	// 先占位,保证父操作的结果排在子操作之前
+	index := len(*exec.outcomes)
+	*exec.outcomes = append(*exec.outcomes, types.ActionOutcome{Path: path, Type: typeName})
+	exec.current = index
+
+	start := time.Now()
+	attempts := 0
+	for attempt := range base.Retries + 1 {
+		if attempt > 0 {
+			backoff := retryBackoff(base.RetryBackoff, attempt)
+			log.Printf("操作 %s 第%d次尝试失败,%v 后重试: %v", path, attempt, backoff, err)
+			if sleepErr := sleep(ctx, backoff); sleepErr != nil {
+				break
+			}
+		}
+		attempts++
+		err = e.attempt(ctx, exec, path, action, base.Timeout)
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+	}
+
+	outcome := &(*exec.outcomes)[index]
+	outcome.Attempts = attempts
+	outcome.Duration = time.Since(start)
+	switch {
+	case err == nil:
+		outcome.Status = types.ActionSucceeded
+	case base.Optional && ctx.Err() == nil:
+		outcome.Status = types.ActionIgnored
+		outcome.Error = err.Error()
+	default:
+		outcome.Status = types.ActionFailed
+		outcome.Error = err.Error()
+	}
+	if _, isControl := action.(param.ActionContainer); !isControl && exec.opts.AfterAction != nil {
+		if hookErr := exec.opts.AfterAction(*outcome); hookErr != nil {
+			return hookErr
+		}
+	}
+
+	switch outcome.Status {
+	case types.ActionIgnored:
+		log.Printf("可选操作 %s 失败,继续执行: %v", path, err)
+		return nil
+	case types.ActionFailed:
+		return err
+	}
+	if exec.opts.Humanize {
+		return sleep(ctx, jitter(base.Delay))
+	}
+	return sleep(ctx, base.Delay)
+}
+
+// attempt 执行一次操作,普通操作未指定超时时间时使用 defaultActionTimeout,控制流操作默认不限时
+func (e *rodExecutor) attempt(ctx context.Context, exec *execution, path string, action param.Action, timeout time.Duration) error {
+	_, isControl := action.(param.ActionContainer)
+	if timeout <= 0 && !isControl {
+		timeout = defaultActionTimeout
+		// 自动滚动有自己的时长上限,默认超时时间在上限的基础上留出余量
+		if a, ok := action.(*param.AutoScrollAction); ok {
+			maxTime := a.MaxTime
+			if maxTime <= 0 {
+				maxTime = defaultAutoScrollMaxTime
+			}
+			timeout += maxTime
+		}
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if isControl {
+		// 控制流操作本身不触发请求,由子操作各自等待网络空闲
+		return e.performControl(ctx, exec, path, action)
+	}
+
+	start := time.Now()
+	page := exec.page.Context(ctx)
+	// 点击可能触发导航,导航开始前无法得知目标地址,在导航后对新地址补做检查
+	var beforeURL string
+	if causesNavigation(action) && exec.opts.BeforeNavigate != nil {
+		beforeURL = documentURL(page)
+	}
+	wait := e.waitRequestIdle(ctx, page, exec.opts)
+	err := e.perform(ctx, exec, page, action)
+	wait()
+	if err == nil && beforeURL != "" {
+		if afterURL := documentURL(page); afterURL != "" && afterURL != beforeURL {
+			err = e.beforeNavigate(ctx, exec, afterURL)
+		}
+	}
+	if _, ok := action.(*param.WaitForNetworkAction); !ok {
+		*exec.prevStart = start
+		exec.responses.discardBefore(start)
+	}
+	return err
+}
+
+func (e *rodExecutor) perform(ctx context.Context, exec *execution, page *rod.Page, action param.Action) error {
+	var err error
+	sc := withContext(exec.scope, ctx)
+	switch a := action.(type) {
+	case *param.ClickAction:
+		element, err := findElement(sc, a.Selector)
+		if err != nil {
+			return fmt.Errorf("点击操作失败: %v", err)
+		}
+		err = e.click(ctx, exec, page, element)
+		if err != nil {
+			return fmt.Errorf("点击操作失败: %v", err)
+		}
+	case *param.ClickXAction:
+		element, err := sc.ElementX(a.Selector)
+		if err != nil {
+			return fmt.Errorf("点击X操作失败: %v", err)
+		}
+		err = e.click(ctx, exec, page, element)
+		if err != nil {
+			return fmt.Errorf("点击X操作失败: %v", err)
+		}
+	case *param.ScrollAction:
+		if exec.opts.Humanize {
+			if err := humanScroll(ctx, page, float64(a.ScrollY)); err != nil {
+				return fmt.Errorf("滚动操作失败: %v", err)
+			}
+			break
+		}
+		_, err = page.Eval(`
+			(scrollY) => {
+				window.scrollBy({
+					top: scrollY,
+					behavior: 'smooth'
+				});
+			}
+		`, a.ScrollY)
+		if err != nil {
+			return fmt.Errorf("滚动操作失败: %v", err)
+		}
+	case *param.JavaScriptAction:
+		err = e.executeJavaScript(ctx, sc, page, a)
+		if err != nil {
+			return fmt.Errorf("执行JavaScript操作失败: %v", err)
+		}
+	case *param.InputTextAction:
+		element, err := findElement(sc, a.Selector)
+		if err != nil {
+			return fmt.Errorf("输入操作失败: %v", err)
+		}
+		if a.Clear {
+			if err := element.SelectAllText(); err != nil {
+				return fmt.Errorf("输入操作失败: %v", err)
+			}
+		}
+		if err := e.input(ctx, exec, page, element, a.Text); err != nil {
+			return fmt.Errorf("输入操作失败: %v", err)
+		}
+	case *param.SelectOptionAction:
+		element, err := findElement(sc, a.Selector)
+		if err != nil {
+			return fmt.Errorf("选择操作失败: %v", err)
+		}
+		if err := element.Select(a.Options, true, selectorType(a.MatchBy)); err != nil {
+			return fmt.Errorf("选择操作失败: %v", err)
+		}
+	case *param.HoverAction:
+		element, err := findElement(sc, a.Selector)
+		if err != nil {
+			return fmt.Errorf("悬停操作失败: %v", err)
+		}
+		if err := e.hover(ctx, exec, page, element); err != nil {
+			return fmt.Errorf("悬停操作失败: %v", err)
+		}
+	case *param.PressKeyAction:
+		if err := e.pressKey(sc, page, a); err != nil {
+			return fmt.Errorf("按键操作失败: %v", err)
+		}
+	case *param.WaitForSelectorAction:
+		visible := a.State != "hidden"
+		if err := waitVisible(sc, a.Selector, visible); err != nil {
+			return fmt.Errorf("等待元素 %s 失败: %v", a.Selector, err)
+		}
+	case *param.WaitForNetworkAction:
+		reg, err := regexp.Compile(proto.PatternToReg(a.URLPattern))
+		if err != nil {
+			return fmt.Errorf("URL模式无效: %v", err)
+		}
+		if err := exec.responses.wait(ctx, reg, *exec.prevStart); err != nil {
+			return fmt.Errorf("等待响应 %s 失败: %v", a.URLPattern, err)
+		}
+	case *param.NavigateAction:
+		if err := e.beforeNavigate(ctx, exec, a.URL); err != nil {
+			return err
+		}
+		if err := page.Navigate(a.URL); err != nil {
+			return fmt.Errorf("导航失败: %v", err)
+		}
+		if err := page.WaitLoad(); err != nil {
+			return fmt.Errorf("等待页面加载失败: %v", err)
+		}
+	case *param.AutoScrollAction:
+		if err := e.autoScroll(ctx, exec, page, a); err != nil {
+			return fmt.Errorf("自动滚动操作失败: %w", err)
+		}
+	default:
+		return fmt.Errorf("未知操作类型: %T", a)
+	}
+	return nil
+}
+
+// beforeNavigate 在操作改变页面地址时调用 ExecuteOptions.BeforeNavigate
+// 返回的错误保留原始错误链,以便报告识别 robots.txt 禁止访问等原因
+func (e *rodExecutor) beforeNavigate(ctx context.Context, exec *execution, url string) error {
+	if exec.opts.BeforeNavigate == nil {
+		return nil
+	}
+	if err := exec.opts.BeforeNavigate(ctx, url); err != nil {
+		return fmt.Errorf("访问 %s 前检查失败: %w", url, err)
+	}
+	return nil
+}
+
+// causesNavigation 判断操作是否可能在页面内触发导航,这类操作之后检查新的页面地址
+func causesNavigation(action param.Action) bool {
+	switch action.(type) {
+	case *param.ClickAction, *param.ClickXAction:
+		return true
+	}
+	return false
+}
+
+// documentURL 返回页面当前去掉 # 片段的URL,只改变片段不算导航,获取失败时返回空字符串
+func documentURL(page *rod.Page) string {
+	info, err := page.Info()
+	if err != nil {
+		return ""
+	}
+	url, _, _ := strings.Cut(info.URL, "#")
+	return url
+}
+
+func (e *rodExecutor) click(ctx context.Context, exec *execution, page *rod.Page, element *rod.Element) error {
+	if exec.opts.Humanize {
+		return humanClick(ctx, page, element)
+	}
+	return element.Click(proto.InputMouseButtonLeft, 1)
+}
+
+func (e *rodExecutor) hover(ctx context.Context, exec *execution, page *rod.Page, element *rod.Element) error {
+	if exec.opts.Humanize {
+		return humanHover(ctx, page, element)
+	}
+	return element.Hover()
+}
+
+func (e *rodExecutor) input(ctx context.Context, exec *execution, page *rod.Page, element *rod.Element, text string) error {
+	if exec.opts.Humanize {
+		return humanType(ctx, page, element, text)
+	}
+	return element.Input(text)
+}
+
+func (e *rodExecutor) performControl(ctx context.Context, exec *execution, path string, action param.Action) error {
+	sc := withContext(exec.scope, ctx)
+	switch a := action.(type) {
+	case *param.RepeatAction:
+		return e.repeat(ctx, exec, path, a)
+	case *param.IfExistsAction:
+		has, _, err := sc.Has(a.Selector)
+		if err != nil {
+			return fmt.Errorf("条件操作查找元素失败: %v", err)
+		}
+		if has {
+			return e.runActions(ctx, exec, path, a.Actions)
+		}
+		if err := e.runActions(ctx, exec, path+".else", a.Else); err != nil {
+			return fmt.Errorf("else: %w", err)
+		}
+		return nil
+	case *param.ForEachElementAction:
+		elements, err := sc.Elements(a.Selector)
+		if err != nil {
+			return fmt.Errorf("遍历操作查找元素失败: %v", err)
+		}
+		if a.Limit > 0 && len(elements) > a.Limit {
+			elements = elements[:a.Limit]
+		}
+		for i, el := range elements {
+			child := *exec
+			child.scope = el
+			if err := e.runActions(ctx, &child, fmt.Sprintf("%s.elements[%d]", path, i), a.Actions); err != nil {
+				return fmt.Errorf("elements[%d]: %w", i, err)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("未知控制流操作类型: %T", a)
+	}
+}
+
+func (e *rodExecutor) repeat(ctx context.Context, exec *execution, path string, action *param.RepeatAction) error {
+	times := action.Times
+	if times == 0 {
+		times = defaultMaxRepeat
+	}
+	for round := range times {
+		stop, err := e.shouldStopRepeat(ctx, exec, action)
+		if err != nil {
+			return err
+		}
+		if stop {
+			log.Printf("重复操作满足停止条件,共执行 %d 轮", round)
+			return nil
+		}
+		if err := e.runActions(ctx, exec, fmt.Sprintf("%s.rounds[%d]", path, round), action.Actions); err != nil {
+			return fmt.Errorf("rounds[%d]: %w", round, err)
+		}
+	}
+	if action.Times > 0 {
+		return nil
+	}
+	// 只指定了停止条件时,用完默认轮数仍未满足说明条件有误或页面没有按预期变化
+	stop, err := e.shouldStopRepeat(ctx, exec, action)
+	if err != nil {
+		return err
+	}
+	if !stop {
+		return fmt.Errorf("重复操作执行 %d 轮后仍未满足停止条件", times)
+	}
+	log.Printf("重复操作满足停止条件,共执行 %d 轮", times)
+	return nil
+}
+
+func (e *rodExecutor) shouldStopRepeat(ctx context.Context, exec *execution, action *param.RepeatAction) (bool, error) {
+	if action.UntilGone != "" {
+		has, _, err := withContext(exec.scope, ctx).Has(action.UntilGone)
+		if err != nil {
+			return false, fmt.Errorf("检查停止条件失败: %v", err)
+		}
+		if !has {
+			return true, nil
+		}
+	}
+	if action.UntilFalse != nil {
+		if exec.opts.Responses == nil {
+			return false, fmt.Errorf("未监听网络响应,无法检查 until_false 条件")
+		}
+		resp := exec.opts.Responses.Latest(action.UntilFalse.URLPattern)
+		if resp == nil {
+			return false, nil
+		}
+		value, ok := lookupJSONPath(resp.GetContent(), action.UntilFalse.Path)
+		if ok && value == false {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// waitRequestIdle 必须在触发操作前调用,返回的函数在操作后等待匹配的请求全部完成
+func (e *rodExecutor) waitRequestIdle(ctx context.Context, page *rod.Page, opts *ExecuteOptions) func() {
+	ctx, cancel := context.WithTimeout(ctx, requestIdleTimeout)
+	includes := patternsToReg(opts.WaitIncludes)
+	excludes := patternsToReg(opts.WaitExcludes)
+	wait := page.Context(ctx).WaitRequestIdle(requestIdleDuration, includes, excludes, nil)
+	return func() {
+		defer cancel()
+		wait()
+	}
+}
+
+// executeJavaScript 在元素范围内执行时,脚本中的 this 指向当前元素
+func (e *rodExecutor) executeJavaScript(ctx context.Context, sc scope, page *rod.Page, action *param.JavaScriptAction) error {
+	var result *proto.RuntimeRemoteObject
+	var err error
+	if el, ok := sc.(*rod.Element); ok {
+		result, err = el.Eval(action.JavaScript, action.JavaScriptArgs...)
+	} else {
+		result, err = page.Eval(action.JavaScript, action.JavaScriptArgs...)
+	}
+	if err != nil {
+		return fmt.Errorf("执行JavaScript失败: %v", err)
+	}
+	jsonResult, err := result.Value.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("JSON序列化失败: %v", err)
+	}
+
+	if action.ProcessFunc == nil {
+		return nil
+	}
+
+	info, err := page.Info()
+	if err != nil {
+		return fmt.Errorf("获取页面信息失败: %v", err)
+	}
+	err = action.ProcessFunc(ctx,
+		&types.HtmlContent{
+			Url:     info.URL,
+			Content: jsonResult,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("处理JavaScript内容失败: %v", err)
+	}
+	return nil
+}
+
+func (e *rodExecutor) pressKey(sc scope, page *rod.Page, action *param.PressKeyAction) error {
+	key, ok := param.LookupKey(action.Key)
+	if !ok {
+		return fmt.Errorf("未知按键: %s", action.Key)
+	}
+	modifiers := make([]input.Key, 0, len(action.Modifiers))
+	for _, name := range action.Modifiers {
+		modifier, ok := param.LookupModifier(name)
+		if !ok {
+			return fmt.Errorf("未知修饰键: %s", name)
+		}
+		modifiers = append(modifiers, modifier)
+	}
+
+	if action.Selector != "" {
+		element, err := findElement(sc, action.Selector)
+		if err != nil {
+			return err
+		}
+		if err := element.Focus(); err != nil {
+			return err
+		}
+	}
+
+	if len(modifiers) == 0 {
+		return page.Keyboard.Type(key)
+	}
+	actions := page.KeyActions()
+	for _, modifier := range modifiers {
+		actions = actions.Press(modifier)
+	}
+	actions = actions.Type(key)
+	for _, modifier := range modifiers {
+		actions = actions.Release(modifier)
+	}
+	return actions.Do()
+}
+
+func selectorType(matchBy string) rod.SelectorType {
+	switch matchBy {
+	case "css":
+		return rod.SelectorTypeCSSSector
+	case "regex":
+		return rod.SelectorTypeRegex
+	default:
+		return rod.SelectorTypeText
+	}
+}
+
+// retryBackoff 返回第 attempt 次重试前的等待时间,每次翻倍,不超过 maxRetryBackoff
+func retryBackoff(backoff time.Duration, attempt int) time.Duration {
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	if backoff >= maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	for range min(attempt-1, maxRetryBackoffShift) {
+		backoff <<= 1
+		if backoff >= maxRetryBackoff {
+			return maxRetryBackoff
+		}
+	}
+	return backoff
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// patternsToReg 将监听器使用的通配符模式转换为 WaitRequestIdle 需要的正则
+func patternsToReg(patterns []string) []string {
+	if len(patterns) == 0 {
+		return nil
+	}
+	regs := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		regs = append(regs, proto.PatternToReg(pattern))
+	}
+	return regs
+}
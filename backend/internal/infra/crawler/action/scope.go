@@ -0,0 +1,52 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-rod/rod"
+)
+
+// scopeSelf 在 ForEachElementAction 的子操作中表示当前元素本身
+const scopeSelf = ":scope"
+
+// scope 是查找元素的范围,*rod.Page 和 *rod.Element 都满足该接口
+type scope interface {
+	Element(selector string) (*rod.Element, error)
+	ElementX(xpath string) (*rod.Element, error)
+	Elements(selector string) (rod.Elements, error)
+	Has(selector string) (bool, *rod.Element, error)
+}
+
+// withContext 让范围内的查找在 ctx 取消时停止
+func withContext(s scope, ctx context.Context) scope {
+	switch v := s.(type) {
+	case *rod.Page:
+		return v.Context(ctx)
+	case *rod.Element:
+		return v.Context(ctx)
+	default:
+		return s
+	}
+}
+
+// findElement 在范围内查找元素,元素范围下的 :scope 返回元素本身
+func findElement(s scope, selector string) (*rod.Element, error) {
+	if el, ok := s.(*rod.Element); ok && selector == scopeSelf {
+		return el, nil
+	}
+	return s.Element(selector)
+}
+
+// waitVisible 等待范围内匹配的元素变为可见或不可见,元素范围下在元素内部查找
+func waitVisible(s scope, selector string, visible bool) error {
+	opts := rod.Eval(visibleJS, selector, visible)
+	switch v := s.(type) {
+	case *rod.Page:
+		return v.Wait(opts)
+	case *rod.Element:
+		return v.Wait(opts)
+	default:
+		return fmt.Errorf("不支持的查找范围: %T", s)
+	}
+}
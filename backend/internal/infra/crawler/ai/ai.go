@@ -9,10 +9,29 @@ import (
 type AICrawler interface {
 	CloseAll() error
 	CloseRouter() error
-	NavigateURL(url string) error
-	ExecuteActions(actions []param.Action, waitIncludes, waitExcludes []string) error
+	// NavigateURL 在配置开启 robots 时会先检查 robots.txt, ignoreRobots 为 true 时跳过检查
+	NavigateURL(ctx context.Context, url string, ignoreRobots bool) error
+	// ExecuteActions 中的 navigate 等操作与 NavigateURL 一样检查 robots.txt
+	ExecuteActions(ctx context.Context, actions []param.Action, opts ActionOptions) ([]types.ActionOutcome, error)
 	GetHTML() (string, error)
 	CleanHTML(html string, candidates, includeTags, excludeTags []string) (string, error)
 	SetListener(ctx context.Context, urlPatterns []string, respCh chan *types.NetworkResponse)
+	// BlockRequests 按拦截策略拦截请求,在 SetListener 之后、RouterRun 之前调用
+	BlockRequests(policy *param.BlockPolicy) error
 	RouterRun()
+	// StartArtifacts 开始保存任务的调试产物, opts 中没有开启任何产物时不保存
+	StartArtifacts(taskID string, opts *param.ArtifactOptions) error
+	// FinishArtifacts 保存最终截图和PDF,返回产物目录和文件列表
+	FinishArtifacts() (dir string, files []string)
+}
+
+// ActionOptions 是 ExecuteActions 的附加参数
+type ActionOptions struct {
+	// WaitIncludes 和 WaitExcludes 是操作后等待网络空闲时关注的URL模式
+	WaitIncludes []string
+	WaitExcludes []string
+	// IgnoreRobots 为 true 时操作中的导航不检查 robots.txt
+	IgnoreRobots bool
+	// Humanize 为 true 时模拟真实用户操作,见 action.ExecuteOptions.Humanize
+	Humanize bool
 }
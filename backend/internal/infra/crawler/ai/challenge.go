@@ -0,0 +1,23 @@
+package ai
+
+import (
+	"context"
+	"crawleragent-v2/internal/infra/crawler/challenge"
+	"log"
+)
+
+// handleChallenge 检查页面是否出现反爬验证,出现时交给 solver 处理,
+// 处理完成且验证消失后返回 nil;否则保存截图并返回包装了 challenge.ErrChallenged 的错误
+func (c *aiCrawler) handleChallenge(ctx context.Context, stage string) error {
+	found, err := challenge.Handle(ctx, c.detector, c.solver, c.page)
+	if found == nil {
+		return nil
+	}
+	if err != nil {
+		log.Printf("%s 后的验证未能处理: %v", stage, err)
+		c.screenshot("challenge")
+		return err
+	}
+	log.Printf("%s 后的验证已处理, 继续执行", stage)
+	return nil
+}
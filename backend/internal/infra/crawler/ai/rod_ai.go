@@ -4,10 +4,16 @@ import (
 	"context"
 	"crawleragent-v2/internal/config"
 	"crawleragent-v2/internal/infra/crawler"
+	"crawleragent-v2/internal/infra/crawler/action"
+	"crawleragent-v2/internal/infra/crawler/artifact"
+	"crawleragent-v2/internal/infra/crawler/block"
+	"crawleragent-v2/internal/infra/crawler/challenge"
+	"crawleragent-v2/internal/infra/crawler/robots"
 	"crawleragent-v2/param"
 	"crawleragent-v2/types"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"time"
 
@@ -18,9 +24,21 @@ import (
 
 type aiCrawler struct {
 	// browser 是全局浏览器实例，用于创建页面和连接
-	browser *rod.Browser
-	page    *rod.Page
-	router  *rod.HijackRouter
+	browser  *rod.Browser
+	page     *rod.Page
+	router   *rod.HijackRouter
+	executor action.Executor
+	// recorder 记录监听到的响应,供控制流操作读取
+	recorder action.ResponseRecorder
+	// robots 为空时不检查 robots.txt
+	robots robots.Robots
+	// detector 为空时不检测反爬验证, solver 为空时遇到验证直接返回错误
+	detector challenge.Detector
+	solver   challenge.Solver
+	// artifactDir 是产物的根目录, artifacts 在 StartArtifacts 和 FinishArtifacts 之间不为空
+	artifactDir  string
+	artifacts    artifact.Recorder
+	artifactOpts *param.ArtifactOptions
 }
 
 func InitAICrawler(cfg *config.Config) (AICrawler, error) {
@@ -54,11 +72,24 @@ func InitAICrawler(cfg *config.Config) (AICrawler, error) {
 	if err != nil {
 		return nil, fmt.Errorf("应用Stealth插件失败: %v", err)
 	}
-	return &aiCrawler{
-		browser: browser,
-		page:    page,
-		router:  nil,
-	}, nil
+	crawler := &aiCrawler{
+		browser:     browser,
+		page:        page,
+		router:      nil,
+		executor:    action.InitExecutor(),
+		recorder:    action.InitResponseRecorder(),
+		artifactDir: cfg.Artifacts.Dir,
+	}
+	if cfg.Robots.Enabled {
+		crawler.robots = robots.InitRobots(cfg.Robots.UserAgent, cfg.Robots.IgnoreHosts, cfg.Robots.CacheTTL)
+	}
+	if crawler.detector = challenge.InitDetector(cfg); crawler.detector != nil {
+		crawler.solver, err = challenge.InitSolver(cfg, crawler.detector)
+		if err != nil {
+			return nil, fmt.Errorf("初始化验证处理器失败: %v", err)
+		}
+	}
+	return crawler, nil
 }
 
 func (c *aiCrawler) CloseAll() error {
@@ -93,6 +124,8 @@ func (c *aiCrawler) CloseRouter() error {
 	// 关闭路由器
 	if c.router != nil {
 		err := c.router.Stop()
+		// 已停止的路由器不能再次运行,下次监听时重新创建
+		c.router = nil
 		log.Printf("关闭路由器")
 		if err != nil {
 			log.Printf("关闭路由器失败: %v", err)
@@ -102,19 +135,53 @@ func (c *aiCrawler) CloseRouter() error {
 	return nil
 }
 
-func (c *aiCrawler) NavigateURL(url string) error {
-	err := c.page.Navigate(url)
+func (c *aiCrawler) NavigateURL(ctx context.Context, url string, ignoreRobots bool) error {
+	if c.robots != nil && !ignoreRobots {
+		if err := c.robots.Wait(ctx, url); err != nil {
+			return err
+		}
+	}
+	err := crawler.NavigateAndSettle(ctx, c.page, url)
 	if err != nil {
 		log.Printf("导航到URL失败: %v", err)
-		return fmt.Errorf("导航失败: %v", err)
+		c.screenshot("navigated")
+		return err
 	}
+	c.screenshot("navigated")
+	return c.handleChallenge(ctx, "navigate")
+}
 
-	c.page.MustWaitStable()
-
-	time.Sleep(2 * time.Second)
+func (c *aiCrawler) StartArtifacts(taskID string, opts *param.ArtifactOptions) error {
+	recorder, err := artifact.InitRecorder(c.artifactDir, taskID, opts)
+	if err != nil {
+		return err
+	}
+	c.artifacts = recorder
+	c.artifactOpts = opts
 	return nil
 }
 
+func (c *aiCrawler) FinishArtifacts() (string, []string) {
+	if c.artifacts == nil {
+		return "", nil
+	}
+	c.screenshot("final")
+	if c.artifactOpts.PDF {
+		c.artifacts.PDF(c.page)
+	}
+	dir, files := c.artifacts.Dir(), c.artifacts.Close()
+	c.artifacts = nil
+	c.artifactOpts = nil
+	return dir, files
+}
+
+// screenshot 在开启截图时保存整页截图
+func (c *aiCrawler) screenshot(name string) {
+	if c.artifacts != nil && c.artifactOpts.Screenshot {
+		c.artifacts.Screenshot(c.page, name)
+	}
+}
+
 func (c *aiCrawler) GetHTML() (string, error) {
 
 	html, err := c.page.HTML()
@@ -153,45 +220,33 @@ func preprocessIncludeTags(includeTags, excludeTags []string) ([]string, []strin
 	return newIncludes, overlaps
 }
 
-func (c *aiCrawler) ExecuteActions(actions []param.Action, waitIncludes, waitExcludes []string) error {
-	if len(actions) == 0 {
-		return nil
+func (c *aiCrawler) ExecuteActions(ctx context.Context, actions []param.Action, actionOpts ActionOptions) ([]types.ActionOutcome, error) {
+	opts := &action.ExecuteOptions{
+		WaitIncludes: actionOpts.WaitIncludes,
+		WaitExcludes: actionOpts.WaitExcludes,
+		Responses:    c.recorder,
+		Humanize:     actionOpts.Humanize,
 	}
-	for _, action := range actions {
-		err := action.Validate()
-		if err != nil {
-			return fmt.Errorf("操作验证失败: %v", err)
+	if c.robots != nil && !actionOpts.IgnoreRobots {
+		opts.BeforeNavigate = c.robots.Wait
+	}
+	if c.artifacts != nil && c.artifactOpts.ActionScreenshots {
+		artifacts := c.artifacts
+		opts.AfterAction = func(outcome types.ActionOutcome) error {
+			artifacts.Screenshot(c.page, outcome.Path)
+			return nil
 		}
-
-		switch a := action.(type) {
-		case *param.ClickAction:
-			element, err := c.page.Element(a.Selector)
-			if err != nil {
-				return fmt.Errorf("点击操作失败: %v", err)
-			}
-			err = element.Click(proto.InputMouseButtonLeft, 1)
-			if err != nil {
-				return fmt.Errorf("点击操作失败: %v", err)
+	}
+	if c.detector != nil {
+		screenshot := opts.AfterAction
+		opts.AfterAction = func(outcome types.ActionOutcome) error {
+			if screenshot != nil {
+				screenshot(outcome)
 			}
-			c.page.WaitRequestIdle(500*time.Millisecond, waitIncludes, waitExcludes, nil)
-			time.Sleep(a.Delay)
-		case *param.ScrollAction:
-			_, err = c.page.Eval(
-				`
-				(scrollY) => {
-					window.scrollTo({
-						top: scrollY,
-						behavior: 'smooth'
-					});
-				}
-			`, a.ScrollY)
-			c.page.WaitRequestIdle(500*time.Millisecond, waitIncludes, waitExcludes, nil)
-			time.Sleep(a.Delay)
-		default:
-			return fmt.Errorf("未知操作类型: %T", a)
+			return c.handleChallenge(ctx, outcome.Path)
 		}
 	}
-	return nil
+	return c.executor.ExecuteActions(ctx, c.page, actions, opts)
 }
 
 func (c *aiCrawler) preProcessHTML(tempPage *rod.Page, candidates []string) (string, error) {
@@ -377,6 +432,9 @@ func (c *aiCrawler) SetListener(ctx context.Context, urlPatterns []string, respC
 	if c.router == nil {
 		c.router = c.browser.HijackRequests()
 	}
+	// 每次处理新页面时重新记录,避免上一次的响应满足本次控制流操作的停止条件
+	recorder := action.InitResponseRecorder()
+	c.recorder = recorder
 	for _, urlPattern := range urlPatterns {
 		c.router.MustAdd(urlPattern, func(hijack *rod.Hijack) {
 			select {
@@ -384,18 +442,45 @@ func (c *aiCrawler) SetListener(ctx context.Context, urlPatterns []string, respC
 				return
 			default:
 			}
-			hijack.MustLoadResponse()
+			started := time.Now()
+			if err := hijack.LoadResponse(http.DefaultClient, true); err != nil {
+				log.Printf("加载响应失败: %s, %v", hijack.Request.URL(), err)
+				return
+			}
+			if c.artifacts != nil {
+				c.artifacts.RecordRequest(hijack, started)
+			}
 			body := hijack.Response.Body()
 			log.Printf("监听成功: %s, 响应长度: %d", urlPattern, len(body))
-			respCh <- &types.NetworkResponse{
+			resp := &types.NetworkResponse{
 				Url:        hijack.Request.URL().String(),
 				UrlPattern: urlPattern,
 				Body:       body,
 			}
+			recorder.Record(resp)
+			respCh <- resp
 		})
 	}
 }
 
+func (c *aiCrawler) BlockRequests(policy *param.BlockPolicy) error {
+	blocker, err := block.InitBlocker(policy)
+	if err != nil {
+		return fmt.Errorf("创建拦截策略失败: %v", err)
+	}
+	if blocker == nil {
+		return nil
+	}
+	if c.router == nil {
+		c.router = c.browser.HijackRequests()
+	}
+	return blocker.Apply(c.router)
+}
+
 func (c *aiCrawler) RouterRun() {
+	// 拦截策略没有规则时不会创建路由器
+	if c.router == nil {
+		return
+	}
 	go c.router.Run()
 }
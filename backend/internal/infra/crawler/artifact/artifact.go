@@ -0,0 +1,21 @@
+package artifact
+
+import (
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+// Recorder 将单个任务的截图、PDF和请求日志写入 <dir>/<taskID>,写入失败只打印日志,不影响任务
+type Recorder interface {
+	// Dir 是任务的产物目录
+	Dir() string
+	// Screenshot 保存整页截图,name 不含扩展名
+	Screenshot(page *rod.Page, name string)
+	// PDF 保存页面PDF,只在 headless 模式下可用
+	PDF(page *rod.Page)
+	// RecordRequest 记录一个已拦截并加载了响应的请求,started 是开始加载响应的时间
+	RecordRequest(hijack *rod.Hijack, started time.Time)
+	// Close 写入请求日志并返回已保存的文件路径
+	Close() []string
+}
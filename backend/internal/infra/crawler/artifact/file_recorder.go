@@ -0,0 +1,139 @@
+package artifact
+
+import (
+	"crawleragent-v2/param"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+type fileRecorder struct {
+	dir  string
+	opts *param.ArtifactOptions
+
+	mu      sync.Mutex
+	files   []string
+	entries []harEntry
+	// seq 为截图编号,保证文件按截取顺序排列
+	seq int
+}
+
+// InitRecorder 创建任务的产物目录,opts 为空或没有开启任何产物时返回 nil
+// taskID 作为目录名,不能逃逸出 baseDir
+func InitRecorder(baseDir, taskID string, opts *param.ArtifactOptions) (Recorder, error) {
+	if opts == nil || !opts.Enabled() {
+		return nil, nil
+	}
+	if err := param.ValidateTaskID(taskID); err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(baseDir, taskID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建产物目录失败: %v", err)
+	}
+	return &fileRecorder{dir: dir, opts: opts}, nil
+}
+
+func (r *fileRecorder) Dir() string {
+	return r.dir
+}
+
+func (r *fileRecorder) Screenshot(page *rod.Page, name string) {
+	r.mu.Lock()
+	r.seq++
+	filename := fmt.Sprintf("%02d_%s.png", r.seq, name)
+	r.mu.Unlock()
+
+	data, err := page.Screenshot(true, &proto.PageCaptureScreenshot{Format: proto.PageCaptureScreenshotFormatPng})
+	if err != nil {
+		log.Printf("截图 %s 失败: %v", filename, err)
+		return
+	}
+	r.write(filename, data)
+}
+
+func (r *fileRecorder) PDF(page *rod.Page) {
+	stream, err := page.PDF(&proto.PagePrintToPDF{PrintBackground: true})
+	if err != nil {
+		log.Printf("保存PDF失败: %v", err)
+		return
+	}
+	defer stream.Close()
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		log.Printf("保存PDF失败: %v", err)
+		return
+	}
+	r.write("page.pdf", data)
+}
+
+func (r *fileRecorder) RecordRequest(hijack *rod.Hijack, started time.Time) {
+	if !r.opts.HAR {
+		return
+	}
+	entry := harEntry{
+		StartedDateTime: started,
+		Time:            float64(time.Since(started).Microseconds()) / 1000,
+		Request: harRequest{
+			Method:  hijack.Request.Method(),
+			URL:     hijack.Request.URL().String(),
+			Headers: toHARHeaders(hijack.Request.Headers()),
+		},
+	}
+	if payload := hijack.Response.Payload(); payload != nil {
+		entry.Response = harResponse{
+			Status:  payload.ResponseCode,
+			Headers: make([]harHeader, 0, len(payload.ResponseHeaders)),
+			Content: harContent{
+				Size:     len(payload.Body),
+				MimeType: hijack.Response.Headers().Get("Content-Type"),
+			},
+		}
+		for _, header := range payload.ResponseHeaders {
+			entry.Response.Headers = append(entry.Response.Headers, harHeader{Name: header.Name, Value: header.Value})
+		}
+	}
+	r.mu.Lock()
+	r.entries = append(r.entries, entry)
+	r.mu.Unlock()
+}
+
+func (r *fileRecorder) Close() []string {
+	if r.opts.HAR {
+		r.mu.Lock()
+		entries := r.entries
+		r.mu.Unlock()
+		har := harLog{Log: harBody{Version: "1.2", Creator: harCreator{Name: "crawleragent", Version: "2"}, Entries: entries}}
+		data, err := json.MarshalIndent(har, "", "  ")
+		if err != nil {
+			log.Printf("序列化请求日志失败: %v", err)
+		} else {
+			r.write("requests.har", data)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.files...)
+}
+
+func (r *fileRecorder) write(filename string, data []byte) {
+	// 操作路径中的 [ ] 等字符在部分系统上不便使用,统一替换
+	filename = strings.NewReplacer("[", "_", "]", "", "/", "_", "\\", "_").Replace(filename)
+	path := filepath.Join(r.dir, filename)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		log.Printf("写入产物 %s 失败: %v", path, err)
+		return
+	}
+	r.mu.Lock()
+	r.files = append(r.files, path)
+	r.mu.Unlock()
+}
@@ -0,0 +1,62 @@
+package artifact
+
+import (
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// 以下结构是 HAR 1.2 格式的子集,只包含拦截请求时能获取到的字段
+
+type harLog struct {
+	Log harBody `json:"log"`
+}
+
+type harBody struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	// Time 是加载响应的耗时,单位毫秒
+	Time     float64     `json:"time"`
+	Request  harRequest  `json:"request"`
+	Response harResponse `json:"response"`
+}
+
+type harRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers []harHeader `json:"headers"`
+}
+
+type harResponse struct {
+	Status  int         `json:"status"`
+	Headers []harHeader `json:"headers"`
+	Content harContent  `json:"content"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func toHARHeaders(headers proto.NetworkHeaders) []harHeader {
+	result := make([]harHeader, 0, len(headers))
+	for name, value := range headers {
+		result = append(result, harHeader{Name: name, Value: value.String()})
+	}
+	return result
+}
@@ -0,0 +1,14 @@
+package block
+
+import (
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Blocker 通过拦截路由器让命中策略的请求直接失败
+type Blocker interface {
+	// Apply 向路由器添加拦截规则,必须在添加监听规则之后调用,监听的请求优先处理
+	Apply(router *rod.HijackRouter) error
+	// Blocked 判断请求是否应被拦截
+	Blocked(url string, resourceType proto.NetworkResourceType) bool
+}
@@ -0,0 +1,138 @@
+package block
+
+import (
+	"bufio"
+	"crawleragent-v2/param"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+type ruleBlocker struct {
+	resourceTypes map[proto.NetworkResourceType]bool
+	domains       map[string]bool
+	patterns      []*regexp.Regexp
+}
+
+// InitBlocker 根据拦截策略创建 Blocker,策略为空或没有任何规则时返回 nil
+func InitBlocker(policy *param.BlockPolicy) (Blocker, error) {
+	if policy == nil {
+		return nil, nil
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	b := &ruleBlocker{
+		resourceTypes: make(map[proto.NetworkResourceType]bool),
+		domains:       make(map[string]bool),
+	}
+	for _, resourceType := range policy.ResourceTypes {
+		cdpName, _ := param.ResourceTypeName(resourceType)
+		b.resourceTypes[proto.NetworkResourceType(cdpName)] = true
+	}
+	domains := policy.Domains
+	for _, path := range policy.DomainFiles {
+		fileDomains, err := readDomainFile(path)
+		if err != nil {
+			return nil, err
+		}
+		domains = append(domains, fileDomains...)
+	}
+	for _, domain := range domains {
+		b.domains[strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "."))] = true
+	}
+	for _, pattern := range policy.URLPatterns {
+		b.patterns = append(b.patterns, regexp.MustCompile(proto.PatternToReg(pattern)))
+	}
+	if len(b.resourceTypes) == 0 && len(b.domains) == 0 && len(b.patterns) == 0 {
+		return nil, nil
+	}
+	log.Printf("请求拦截策略: %d 种资源类型, %d 个域名, %d 个URL模式", len(b.resourceTypes), len(b.domains), len(b.patterns))
+	return b, nil
+}
+
+// readDomainFile 读取域名列表,兼容 hosts 文件格式,每行取最后一列作为域名
+func readDomainFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取域名文件失败: %v", err)
+	}
+	defer file.Close()
+
+	var domains []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		domains = append(domains, fields[len(fields)-1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取域名文件失败: %v", err)
+	}
+	return domains, nil
+}
+
+func (b *ruleBlocker) Apply(router *rod.HijackRouter) error {
+	// 只拦截资源类型时由浏览器按类型暂停请求,否则需要暂停所有请求再逐个判断
+	// 路由器按URL依次匹配处理函数,因此每条规则都使用完整的判断逻辑
+	if len(b.domains) == 0 && len(b.patterns) == 0 {
+		for resourceType := range b.resourceTypes {
+			if err := router.Add("*", resourceType, b.handle); err != nil {
+				return fmt.Errorf("添加拦截规则失败: %v", err)
+			}
+		}
+		return nil
+	}
+	if err := router.Add("*", "", b.handle); err != nil {
+		return fmt.Errorf("添加拦截规则失败: %v", err)
+	}
+	return nil
+}
+
+func (b *ruleBlocker) handle(hijack *rod.Hijack) {
+	if b.Blocked(hijack.Request.URL().String(), hijack.Request.Type()) {
+		hijack.Response.Fail(proto.NetworkErrorReasonBlockedByClient)
+		return
+	}
+	hijack.ContinueRequest(&proto.FetchContinueRequest{})
+}
+
+func (b *ruleBlocker) Blocked(rawURL string, resourceType proto.NetworkResourceType) bool {
+	if b.resourceTypes[resourceType] {
+		return true
+	}
+	if len(b.domains) > 0 {
+		if target, err := url.Parse(rawURL); err == nil && b.matchDomain(strings.ToLower(target.Hostname())) {
+			return true
+		}
+	}
+	for _, pattern := range b.patterns {
+		if pattern.MatchString(rawURL) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchDomain 依次去掉最左边的标签,判断 host 或其上级域名是否在列表中
+func (b *ruleBlocker) matchDomain(host string) bool {
+	for host != "" {
+		if b.domains[host] {
+			return true
+		}
+		i := strings.IndexByte(host, '.')
+		if i < 0 {
+			return false
+		}
+		host = host[i+1:]
+	}
+	return false
+}
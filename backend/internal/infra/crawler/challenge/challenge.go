@@ -0,0 +1,69 @@
+package challenge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/go-rod/rod"
+)
+
+// ErrChallenged 表示页面出现了验证码等反爬验证且未能处理,任务状态为 challenged
+var ErrChallenged = errors.New("遇到反爬验证")
+
+// Challenge 是检测到的反爬验证
+type Challenge struct {
+	// Kind 为触发检测的规则类型: selector / url / status
+	Kind string
+	// Detail 是命中的选择器、URL模式或状态码
+	Detail string
+	// URL 是检测时页面的URL
+	URL string
+}
+
+func (c *Challenge) String() string {
+	return fmt.Sprintf("%s %s (%s)", c.Kind, c.Detail, c.URL)
+}
+
+// Detector 在导航和操作之后检查页面是否出现了反爬验证
+type Detector interface {
+	// Detect 没有检测到验证时返回 nil
+	Detect(page *rod.Page) (*Challenge, error)
+}
+
+// Solver 处理检测到的验证,返回 nil 后会重新检测,验证消失才继续执行任务
+// 实现该接口并在 InitSolver 中注册即可接入新的处理方式
+type Solver interface {
+	Solve(ctx context.Context, page *rod.Page, challenge *Challenge) error
+}
+
+// Handle 检查页面是否出现反爬验证,出现时交给 solver 处理并再次检测确认验证已经消失
+// 没有检测到验证时 found 为 nil;验证未能处理时 err 包装了 ErrChallenged。
+// 检测本身失败只记录日志,不影响任务继续执行
+func Handle(ctx context.Context, detector Detector, solver Solver, page *rod.Page) (found *Challenge, err error) {
+	if detector == nil {
+		return nil, nil
+	}
+	found, err = detector.Detect(page)
+	if err != nil {
+		log.Printf("检测反爬验证失败: %v", err)
+		return nil, nil
+	}
+	if found == nil {
+		return nil, nil
+	}
+
+	log.Printf("遇到验证: %s", found)
+	if solver == nil {
+		err = fmt.Errorf("未配置验证处理器")
+	} else if err = solver.Solve(ctx, page, found); err == nil {
+		if remaining, detectErr := detector.Detect(page); detectErr == nil && remaining != nil {
+			err = fmt.Errorf("验证仍然存在: %s", remaining)
+		}
+	}
+	if err != nil {
+		return found, fmt.Errorf("%w: %s, %v", ErrChallenged, found, err)
+	}
+	return found, nil
+}
@@ -0,0 +1,81 @@
+package challenge
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// externalSolver 将验证发送给外部服务处理,服务返回需要设置的 cookie 和需要执行的脚本
+// 请求体: {"url", "kind", "detail", "screenshot"(base64编码的PNG)}
+// 响应体: {"solved": bool, "cookies": [CDP Network.CookieParam], "javascript": "() => {...}"}
+type externalSolver struct {
+	endpoint string
+	client   *http.Client
+}
+
+type externalRequest struct {
+	URL        string `json:"url"`
+	Kind       string `json:"kind"`
+	Detail     string `json:"detail"`
+	Screenshot string `json:"screenshot,omitempty"`
+}
+
+type externalResponse struct {
+	Solved     bool                        `json:"solved"`
+	Cookies    []*proto.NetworkCookieParam `json:"cookies"`
+	JavaScript string                      `json:"javascript"`
+}
+
+func (s *externalSolver) Solve(ctx context.Context, page *rod.Page, challenge *Challenge) error {
+	req := externalRequest{URL: challenge.URL, Kind: challenge.Kind, Detail: challenge.Detail}
+	if screenshot, err := page.Screenshot(false, nil); err == nil {
+		req.Screenshot = base64.StdEncoding.EncodeToString(screenshot)
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("序列化验证请求失败: %v", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建验证请求失败: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("请求验证服务失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("验证服务返回状态码 %d", resp.StatusCode)
+	}
+	var result externalResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("解析验证服务响应失败: %v", err)
+	}
+	if !result.Solved {
+		return fmt.Errorf("验证服务未能处理验证")
+	}
+
+	page = page.Context(ctx)
+	if len(result.Cookies) > 0 {
+		if err := page.SetCookies(result.Cookies); err != nil {
+			return fmt.Errorf("设置cookie失败: %v", err)
+		}
+	}
+	if result.JavaScript != "" {
+		if _, err := page.Eval(result.JavaScript); err != nil {
+			return fmt.Errorf("执行验证脚本失败: %v", err)
+		}
+	}
+	if err := page.Reload(); err != nil {
+		return fmt.Errorf("刷新页面失败: %v", err)
+	}
+	return page.WaitStable(solverStableDuration)
+}
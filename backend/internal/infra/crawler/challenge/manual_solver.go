@@ -0,0 +1,51 @@
+package challenge
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+const manualPollInterval = 2 * time.Second
+
+// manualSolver 等待用户在有界面的浏览器中手动完成验证,同一时间只处理一个验证
+type manualSolver struct {
+	detector Detector
+	timeout  time.Duration
+	// sem 容量为 1,排队等待时 ctx 取消可以直接返回
+	sem chan struct{}
+}
+
+func (s *manualSolver) Solve(ctx context.Context, page *rod.Page, challenge *Challenge) error {
+	select {
+	case s.sem <- struct{}{}:
+	case <-ctx.Done():
+		return fmt.Errorf("等待处理其他验证时取消: %v", ctx.Err())
+	}
+	defer func() { <-s.sem }()
+
+	log.Printf("请在浏览器中完成验证: %s, 最长等待 %v", challenge, s.timeout)
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	ticker := time.NewTicker(manualPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("等待手动验证超时: %v", ctx.Err())
+		case <-ticker.C:
+		}
+		current, err := s.detector.Detect(page)
+		if err != nil {
+			log.Printf("检测验证状态失败: %v", err)
+			continue
+		}
+		if current == nil {
+			log.Printf("验证已完成: %s", challenge)
+			return nil
+		}
+	}
+}
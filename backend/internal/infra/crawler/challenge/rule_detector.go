@@ -0,0 +1,77 @@
+package challenge
+
+import (
+	"crawleragent-v2/internal/config"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// navigationStatusJS 读取主文档的HTTP状态码,浏览器不支持时返回 0
+const navigationStatusJS = `() => {
+	const entry = performance.getEntriesByType('navigation')[0];
+	return entry && entry.responseStatus ? entry.responseStatus : 0;
+}`
+
+type ruleDetector struct {
+	selectors   []string
+	patterns    []string
+	regexps     []*regexp.Regexp
+	statusCodes map[int]bool
+}
+
+// InitDetector 根据配置创建检测器,未开启或没有任何规则时返回 nil
+func InitDetector(cfg *config.Config) Detector {
+	if !cfg.Challenge.Enabled {
+		return nil
+	}
+	d := &ruleDetector{
+		selectors:   cfg.Challenge.Selectors,
+		statusCodes: make(map[int]bool),
+	}
+	for _, pattern := range cfg.Challenge.URLPatterns {
+		d.patterns = append(d.patterns, pattern)
+		d.regexps = append(d.regexps, regexp.MustCompile(proto.PatternToReg(pattern)))
+	}
+	for _, code := range cfg.Challenge.StatusCodes {
+		d.statusCodes[code] = true
+	}
+	if len(d.selectors) == 0 && len(d.regexps) == 0 && len(d.statusCodes) == 0 {
+		return nil
+	}
+	return d
+}
+
+func (d *ruleDetector) Detect(page *rod.Page) (*Challenge, error) {
+	info, err := page.Info()
+	if err != nil {
+		return nil, fmt.Errorf("获取页面信息失败: %v", err)
+	}
+	for i, re := range d.regexps {
+		if re.MatchString(info.URL) {
+			return &Challenge{Kind: "url", Detail: d.patterns[i], URL: info.URL}, nil
+		}
+	}
+	for _, selector := range d.selectors {
+		has, _, err := page.Has(selector)
+		if err != nil {
+			return nil, fmt.Errorf("查找元素 %s 失败: %v", selector, err)
+		}
+		if has {
+			return &Challenge{Kind: "selector", Detail: selector, URL: info.URL}, nil
+		}
+	}
+	if len(d.statusCodes) > 0 {
+		result, err := page.Eval(navigationStatusJS)
+		if err != nil {
+			return nil, fmt.Errorf("获取页面状态码失败: %v", err)
+		}
+		if status := result.Value.Int(); d.statusCodes[status] {
+			return &Challenge{Kind: "status", Detail: strconv.Itoa(status), URL: info.URL}, nil
+		}
+	}
+	return nil, nil
+}
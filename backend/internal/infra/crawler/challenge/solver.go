@@ -0,0 +1,46 @@
+package challenge
+
+import (
+	"crawleragent-v2/internal/config"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	// SolverManual 等待用户在有界面的浏览器中手动完成验证
+	SolverManual = "manual"
+	// SolverExternal 将验证交给 external_url 指定的外部服务
+	SolverExternal = "external"
+
+	defaultSolveTimeout  = 5 * time.Minute
+	solverStableDuration = time.Second
+)
+
+// InitSolver 根据 challenge.solver 创建处理器,为空时返回 nil,检测到验证后任务直接标记为 challenged
+func InitSolver(cfg *config.Config, detector Detector) (Solver, error) {
+	timeout := cfg.Challenge.Timeout
+	if timeout <= 0 {
+		timeout = defaultSolveTimeout
+	}
+	switch cfg.Challenge.Solver {
+	case "":
+		return nil, nil
+	case SolverManual:
+		if cfg.Rod.Headless {
+			log.Printf("警告: headless 模式下无法手动处理验证, 请设置 rod.headless 为 false")
+		}
+		return &manualSolver{detector: detector, timeout: timeout, sem: make(chan struct{}, 1)}, nil
+	case SolverExternal:
+		if cfg.Challenge.ExternalURL == "" {
+			return nil, fmt.Errorf("external 处理器必须指定 challenge.external_url")
+		}
+		return &externalSolver{
+			endpoint: cfg.Challenge.ExternalURL,
+			client:   &http.Client{Timeout: timeout},
+		}, nil
+	default:
+		return nil, fmt.Errorf("未知的验证处理器: %s", cfg.Challenge.Solver)
+	}
+}
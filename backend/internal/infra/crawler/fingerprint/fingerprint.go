@@ -0,0 +1,18 @@
+package fingerprint
+
+import "crawleragent-v2/internal/config"
+
+const (
+	// RotationPerInstance 每个浏览器实例固定使用一个指纹,与用户数据目录中的状态保持一致
+	RotationPerInstance = "per_instance"
+	// RotationRoundRobin 每个任务依次使用下一个指纹
+	RotationRoundRobin = "round_robin"
+	// RotationRandom 每个任务随机选择一个指纹
+	RotationRandom = "random"
+)
+
+// Rotator 按轮换策略为任务选择指纹
+type Rotator interface {
+	// Pick 为 slot 上执行的任务选择指纹,slot 在 browser 模式下为浏览器实例编号,在 page 模式下为 worker 编号
+	Pick(slot int) *config.FingerprintProfile
+}
@@ -0,0 +1,61 @@
+package fingerprint
+
+import (
+	"crawleragent-v2/internal/config"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sync/atomic"
+)
+
+type profileRotator struct {
+	profiles []config.FingerprintProfile
+	rotation string
+	next     atomic.Uint64
+}
+
+// InitRotator 根据配置创建指纹轮换器,没有配置 profiles 时使用 rod 中的 user_agent 和默认页面大小,
+// 两者都没有配置时返回 nil
+func InitRotator(cfg *config.Config) (Rotator, error) {
+	profiles := cfg.Fingerprint.Profiles
+	if len(profiles) == 0 {
+		if cfg.Rod.UserAgent == "" && (cfg.Rod.DefaultPageWidth <= 0 || cfg.Rod.DefaultPageHeight <= 0) {
+			return nil, nil
+		}
+		profiles = []config.FingerprintProfile{{
+			Name:      "default",
+			UserAgent: cfg.Rod.UserAgent,
+			Width:     cfg.Rod.DefaultPageWidth,
+			Height:    cfg.Rod.DefaultPageHeight,
+		}}
+	}
+	for i := range profiles {
+		if profiles[i].Name == "" {
+			profiles[i].Name = fmt.Sprintf("profiles[%d]", i)
+		}
+	}
+
+	rotation := cfg.Fingerprint.Rotation
+	switch rotation {
+	case "":
+		rotation = RotationPerInstance
+	case RotationPerInstance, RotationRoundRobin, RotationRandom:
+	default:
+		return nil, fmt.Errorf("未知的指纹轮换策略: %s", rotation)
+	}
+	log.Printf("加载了 %d 个指纹, 轮换策略: %s", len(profiles), rotation)
+	return &profileRotator{profiles: profiles, rotation: rotation}, nil
+}
+
+func (r *profileRotator) Pick(slot int) *config.FingerprintProfile {
+	var i int
+	switch r.rotation {
+	case RotationRoundRobin:
+		i = int((r.next.Add(1) - 1) % uint64(len(r.profiles)))
+	case RotationRandom:
+		i = rand.IntN(len(r.profiles))
+	default:
+		i = slot % len(r.profiles)
+	}
+	return &r.profiles[i]
+}
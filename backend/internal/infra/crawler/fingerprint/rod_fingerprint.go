@@ -0,0 +1,58 @@
+package fingerprint
+
+import (
+	"crawleragent-v2/internal/config"
+	"fmt"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Apply 通过 CDP 模拟将指纹应用到页面,需要在导航前调用
+// 每个任务使用新建的页面,为了在同一页面上重复调用时仍然有效,先清除已有的语言和时区覆盖
+func Apply(page *rod.Page, profile *config.FingerprintProfile) error {
+	if profile.UserAgent != "" || profile.AcceptLanguage != "" || profile.Platform != "" {
+		userAgent := profile.UserAgent
+		if userAgent == "" {
+			version, err := proto.BrowserGetVersion{}.Call(page)
+			if err != nil {
+				return fmt.Errorf("获取浏览器UA失败: %v", err)
+			}
+			userAgent = version.UserAgent
+		}
+		err := page.SetUserAgent(&proto.NetworkSetUserAgentOverride{
+			UserAgent:      userAgent,
+			AcceptLanguage: profile.AcceptLanguage,
+			Platform:       profile.Platform,
+		})
+		if err != nil {
+			return fmt.Errorf("设置UA失败: %v", err)
+		}
+	}
+	if profile.Width > 0 && profile.Height > 0 {
+		err := page.SetViewport(&proto.EmulationSetDeviceMetricsOverride{
+			Width:             profile.Width,
+			Height:            profile.Height,
+			DeviceScaleFactor: profile.DeviceScaleFactor,
+			Mobile:            profile.Mobile,
+		})
+		if err != nil {
+			return fmt.Errorf("设置视口失败: %v", err)
+		}
+	}
+	if profile.Locale != "" {
+		// 已有覆盖时再次设置会报错,参数为空表示清除覆盖
+		_ = proto.EmulationSetLocaleOverride{}.Call(page)
+		if err := (proto.EmulationSetLocaleOverride{Locale: profile.Locale}).Call(page); err != nil {
+			return fmt.Errorf("设置语言失败: %v", err)
+		}
+	}
+	if profile.Timezone != "" {
+		// 同上,先清除已有的时区覆盖
+		_ = proto.EmulationSetTimezoneOverride{}.Call(page)
+		if err := (proto.EmulationSetTimezoneOverride{TimezoneID: profile.Timezone}).Call(page); err != nil {
+			return fmt.Errorf("设置时区失败: %v", err)
+		}
+	}
+	return nil
+}
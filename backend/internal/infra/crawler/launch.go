@@ -0,0 +1,39 @@
+package crawler
+
+import (
+	"crawleragent-v2/internal/config"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/go-rod/rod"
+)
+
+// LaunchInstance 使用第 id 个实例的数据目录和调试端口启动单个浏览器,供命令行工具使用
+// extra 在配置选项之后应用,可以覆盖配置,返回的 cleanup 关闭浏览器
+func LaunchInstance(cfg *config.Config, id int, extra ...LauncherOption) (*rod.Browser, func(), error) {
+	dataDir := InstanceDataDir(cfg, id)
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("创建实例数据目录失败: %v", err)
+	}
+	options := append(ConfigOptions(cfg),
+		WithUserDataDir(dataDir),
+		WithRemoteDebuggingPort(cfg.Rod.BasicRemoteDebuggingPort+id),
+	)
+	l := CreateLauncher(cfg.Rod.UserMode, append(options, extra...)...)
+	urlStr, err := l.Launch()
+	if err != nil {
+		return nil, nil, fmt.Errorf("启动浏览器失败: %v", err)
+	}
+	browser := rod.New().ControlURL(urlStr).Trace(cfg.Rod.Trace)
+	if err := browser.Connect(); err != nil {
+		l.Kill()
+		return nil, nil, fmt.Errorf("连接浏览器失败: %v", err)
+	}
+	return browser, func() {
+		if err := browser.Close(); err != nil {
+			log.Printf("关闭浏览器失败: %v", err)
+		}
+		l.Kill()
+	}, nil
+}
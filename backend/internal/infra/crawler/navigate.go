@@ -0,0 +1,33 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+const (
+	// stableDuration 是判断页面稳定时 DOM 需要保持不变的时长
+	stableDuration = time.Second
+	// settleDelay 页面稳定后再等待的时间,留给页面中的异步请求
+	settleDelay = 2 * time.Second
+)
+
+// NavigateAndSettle 导航到 url 并等待页面稳定, ctx 取消时立即返回,页面关闭或 CDP 出错时返回错误
+func NavigateAndSettle(ctx context.Context, page *rod.Page, url string) error {
+	page = page.Context(ctx)
+	if err := page.Navigate(url); err != nil {
+		return fmt.Errorf("导航失败: %v", err)
+	}
+	if err := page.WaitStable(stableDuration); err != nil {
+		return fmt.Errorf("等待页面稳定失败: %v", err)
+	}
+	select {
+	case <-time.After(settleDelay):
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待页面加载时取消: %v", ctx.Err())
+	}
+}
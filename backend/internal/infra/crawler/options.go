@@ -1,6 +1,7 @@
 package crawler
 
 import (
+	"crawleragent-v2/internal/config"
 	"fmt"
 
 	"github.com/go-rod/rod/lib/launcher"
@@ -25,6 +26,27 @@ func CreateLauncher(userMode bool, options ...LauncherOption) *launcher.Launcher
 	return l
 }
 
+// ConfigOptions 返回配置文件中的通用启动选项,数据目录、调试端口和代理由调用方追加
+func ConfigOptions(cfg *config.Config) []LauncherOption {
+	return []LauncherOption{
+		WithBin(cfg.Rod.Bin),
+		WithHeadless(cfg.Rod.Headless),
+		WithDisableBlinkFeatures(cfg.Rod.DisableBlinkFeatures),
+		WithIncognito(cfg.Rod.Incognito),
+		WithDisableDevShmUsage(cfg.Rod.DisableDevShmUsage),
+		WithNoSandbox(cfg.Rod.NoSandbox),
+		WithUserAgent(cfg.Rod.UserAgent),
+		WithLeakless(cfg.Rod.Leakless),
+		WithDisableBackgroundNetworking(cfg.Rod.DisableBackgroundNetworking),
+		WithDisableBackgroundTimerThrottling(cfg.Rod.DisableBackgroundTimerThrottling),
+	}
+}
+
+// InstanceDataDir 返回浏览器池中第 id 个实例的用户数据目录
+func InstanceDataDir(cfg *config.Config, id int) string {
+	return fmt.Sprintf("%s/instance_%d", cfg.Rod.UserDataDir, id)
+}
+
 func WithUserDataDir(dir string) LauncherOption {
 	return func(l *launcher.Launcher) {
 		if dir != "" {
@@ -142,3 +164,12 @@ func WithRemoteDebuggingPort(port int) LauncherOption {
 		}
 	}
 }
+
+// WithProxy 设置代理服务器,如 http://127.0.0.1:7890,认证信息需要通过 proxy.HandleAuth 提供
+func WithProxy(server string) LauncherOption {
+	return func(l *launcher.Launcher) {
+		if server != "" {
+			l.Set("proxy-server", server)
+		}
+	}
+}
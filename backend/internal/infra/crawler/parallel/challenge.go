@@ -0,0 +1,41 @@
+package parallel
+
+import (
+	"context"
+	"crawleragent-v2/internal/infra/crawler/challenge"
+	"crawleragent-v2/types"
+	"log"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+// handleChallenge 检查页面是否出现反爬验证,出现时暂停当前任务并交给 solver 处理,
+// 处理结果记录到任务报告中,验证消失后返回 nil,任务继续执行;否则返回包装了 challenge.ErrChallenged 的错误
+func (c *browserPoolCrawler) handleChallenge(ctx context.Context, workerID int, page *rod.Page, report *taskReport, stage string) error {
+	found, err := challenge.Handle(ctx, c.detector, c.solver, page)
+	if found == nil {
+		return nil
+	}
+
+	record := types.ChallengeRecord{
+		Kind:   found.Kind,
+		Detail: found.Detail,
+		URL:    found.URL,
+		Stage:  stage,
+		Time:   time.Now(),
+		Solved: err == nil,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	report.mu.Lock()
+	report.report.Challenges = append(report.report.Challenges, record)
+	report.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	log.Printf("Worker %d 在 %s 后遇到的验证已处理, 继续执行任务", workerID, stage)
+	return nil
+}
@@ -0,0 +1,107 @@
+package parallel
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+const healthCheckTimeout = 5 * time.Second
+
+// checkHealth 检查浏览器进程是否存在以及 CDP 连接是否可用
+func (c *browserPoolCrawler) checkHealth(inst *browserInstance) error {
+	if inst.browser == nil {
+		return errBrowserUnavailable
+	}
+	if inst.launcher != nil {
+		if pid := inst.launcher.PID(); pid > 0 {
+			if err := processAlive(pid); err != nil {
+				return fmt.Errorf("浏览器进程 %d 已退出: %v", pid, err)
+			}
+		}
+	}
+	if _, err := (proto.BrowserGetVersion{}).Call(inst.browser.Timeout(healthCheckTimeout)); err != nil {
+		return fmt.Errorf("CDP 连接不可用: %v", err)
+	}
+	return nil
+}
+
+// ensureHealthy 在浏览器不可用时使用相同的调试端口和数据目录重启
+func (c *browserPoolCrawler) ensureHealthy(inst *browserInstance) error {
+	err := c.checkHealth(inst)
+	if err == nil {
+		return nil
+	}
+	// 关闭过程中不再重启,避免与 Close 关闭浏览器冲突
+	if c.isClosed() {
+		return fmt.Errorf("浏览器 %d 不可用: %w", inst.id, ErrClosed)
+	}
+	log.Printf("浏览器 %d 不可用: %v, 开始重启", inst.id, err)
+	if err := c.shutdown(inst); err != nil {
+		log.Printf("关闭浏览器 %d 失败: %v", inst.id, err)
+	}
+	if err := c.launchWithProxy(inst); err != nil {
+		c.shutdown(inst)
+		return fmt.Errorf("重启浏览器 %d 失败: %v", inst.id, err)
+	}
+	log.Printf("浏览器 %d 重启成功", inst.id)
+	return nil
+}
+
+// startSupervisor 在配置了检查间隔时启动健康检查协程, Close 时停止
+func (c *browserPoolCrawler) startSupervisor() {
+	interval := c.cfg.Rod.HealthCheckInterval
+	if interval <= 0 {
+		return
+	}
+	c.stopSupervisor = make(chan struct{})
+	c.supervisorDone = make(chan struct{})
+	go c.supervise(interval)
+}
+
+// supervise 定期检查空闲的浏览器,正在执行任务的浏览器在放回池中后的下一轮检查
+// page 模式下共用的浏览器每轮都检查
+func (c *browserPoolCrawler) supervise(interval time.Duration) {
+	defer close(c.supervisorDone)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stopSupervisor:
+			return
+		case <-ticker.C:
+		}
+		if c.pageSlots != nil {
+			c.superviseShared()
+			continue
+		}
+		// 只取出当前空闲的实例,不阻塞 worker
+		var idle []*browserInstance
+	drain:
+		for range len(c.all) {
+			select {
+			case inst := <-c.instances:
+				idle = append(idle, inst)
+			default:
+				break drain
+			}
+		}
+		for _, inst := range idle {
+			if err := c.ensureHealthy(inst); err != nil {
+				log.Printf("%v", err)
+			}
+			c.instances <- inst
+		}
+	}
+}
+
+// superviseShared 检查 page 模式下共用的浏览器,与创建页面时的重启互斥
+func (c *browserPoolCrawler) superviseShared() {
+	c.pageMu.Lock()
+	defer c.pageMu.Unlock()
+	if err := c.ensureHealthy(c.all[0]); err != nil {
+		log.Printf("%v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package parallel
+
+import (
+	"crawleragent-v2/internal/config"
+	"crawleragent-v2/internal/infra/crawler"
+	"crawleragent-v2/internal/infra/crawler/proxy"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+)
+
+const (
+	defaultProxyMaxFailures = 3
+	defaultProxyCheckURL    = "https://www.baidu.com"
+	proxyCheckTimeout       = 30 * time.Second
+	// maxLaunchAttempts 是与代理无关的启动失败时,使用同一代理重试的次数
+	maxLaunchAttempts = 3
+	launchRetryDelay  = 2 * time.Second
+)
+
+// errBrowserUnavailable 表示实例的浏览器重启失败,任务无法执行
+var errBrowserUnavailable = errors.New("浏览器不可用")
+
+// browserInstance 是浏览器池中的一个浏览器进程,重启时沿用相同的调试端口和数据目录
+type browserInstance struct {
+	id       int
+	dataDir  string
+	launcher *launcher.Launcher
+	browser  *rod.Browser
+	// proxy 为空表示不使用代理
+	proxy *proxy.Proxy
+	// stopAuth 停止回答代理认证,不需要认证时为空
+	stopAuth func()
+	// client 通过实例的代理重新发送拦截到的请求,不使用代理时为 http.DefaultClient
+	client *http.Client
+	// failures 是使用当前代理连续导航失败的次数
+	failures int
+}
+
+func newBrowserInstance(cfg *config.Config, id int) (*browserInstance, error) {
+	dataDir := crawler.InstanceDataDir(cfg, id)
+	err := os.MkdirAll(dataDir, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("创建实例数据目录失败: %v", err)
+	}
+	return &browserInstance{id: id, dataDir: dataDir}, nil
+}
+
+// launch 启动浏览器并建立连接,使用代理时开始回答代理认证并检查代理是否可用
+func (c *browserPoolCrawler) launch(inst *browserInstance) error {
+	options := append(crawler.ConfigOptions(c.cfg),
+		crawler.WithUserDataDir(inst.dataDir),
+		crawler.WithRemoteDebuggingPort(c.cfg.Rod.BasicRemoteDebuggingPort+inst.id),
+	)
+	if inst.proxy != nil {
+		options = append(options, crawler.WithProxy(inst.proxy.Server))
+	}
+	client, err := proxy.HTTPClient(inst.proxy)
+	if err != nil {
+		return fmt.Errorf("%v: %w", err, proxy.ErrProxyUnavailable)
+	}
+	inst.client = client
+	l := crawler.CreateLauncher(c.cfg.Rod.UserMode, options...)
+	urlStr, err := l.Launch()
+	if err != nil {
+		return fmt.Errorf("启动浏览器失败: %v", err)
+	}
+	log.Printf("浏览器 %d 可以连接的URL: %s, 代理: %v", inst.id, urlStr, inst.proxy)
+
+	browser := rod.
+		New().
+		ControlURL(urlStr).
+		Trace(c.cfg.Rod.Trace) // 开启 CDP 通信追踪（日志会输出请求/响应）
+	if err := browser.Connect(); err != nil {
+		l.Kill()
+		return fmt.Errorf("连接浏览器失败: %v", err)
+	}
+	inst.launcher = l
+	inst.browser = browser
+	inst.failures = 0
+
+	if inst.proxy != nil {
+		checkURL := c.cfg.Proxy.CheckURL
+		if checkURL == "" {
+			checkURL = defaultProxyCheckURL
+		}
+		stop, err := proxy.HandleAuth(browser, inst.proxy)
+		if err != nil {
+			return err
+		}
+		inst.stopAuth = stop
+		if err := proxy.Check(browser, inst.proxy, checkURL, proxyCheckTimeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// launchWithProxy 为实例分配代理并启动,代理不可用时淘汰并尝试下一个,
+// 浏览器启动等与代理无关的失败使用同一代理重试,不淘汰代理
+func (c *browserPoolCrawler) launchWithProxy(inst *browserInstance) error {
+	if c.proxyPool == nil {
+		return c.launch(inst)
+	}
+	for {
+		p, err := c.proxyPool.Next()
+		if err != nil {
+			return fmt.Errorf("浏览器 %d 分配代理失败: %v", inst.id, err)
+		}
+		inst.proxy = p
+		err = c.launchRetry(inst)
+		if err == nil {
+			return nil
+		}
+		c.shutdown(inst)
+		if !errors.Is(err, proxy.ErrProxyUnavailable) {
+			return fmt.Errorf("浏览器 %d 启动失败: %v", inst.id, err)
+		}
+		log.Printf("浏览器 %d 的代理 %s 不可用, 淘汰代理: %v", inst.id, p, err)
+		c.proxyPool.Evict(p)
+	}
+}
+
+// launchRetry 启动浏览器,代理不可用时直接返回,其他失败最多尝试 maxLaunchAttempts 次
+func (c *browserPoolCrawler) launchRetry(inst *browserInstance) error {
+	for attempt := 1; ; attempt++ {
+		err := c.launch(inst)
+		if err == nil || errors.Is(err, proxy.ErrProxyUnavailable) || attempt >= maxLaunchAttempts {
+			return err
+		}
+		log.Printf("浏览器 %d 第 %d 次启动失败, 稍后重试: %v", inst.id, attempt, err)
+		c.shutdown(inst)
+		time.Sleep(time.Duration(attempt) * launchRetryDelay)
+	}
+}
+
+// shutdown 关闭浏览器进程,重启前调用以释放调试端口和数据目录
+func (c *browserPoolCrawler) shutdown(inst *browserInstance) error {
+	var err error
+	if inst.stopAuth != nil {
+		inst.stopAuth()
+		inst.stopAuth = nil
+	}
+	if inst.browser != nil {
+		err = inst.browser.Close()
+		inst.browser = nil
+	}
+	if inst.launcher != nil {
+		inst.launcher.Kill()
+		inst.launcher = nil
+	}
+	return err
+}
+
+// recordNavigation 记录导航结果,同一代理连续失败达到上限后淘汰代理并换用新代理重启浏览器
+func (c *browserPoolCrawler) recordNavigation(inst *browserInstance, navErr error) {
+	if inst.proxy == nil {
+		return
+	}
+	if navErr == nil {
+		inst.failures = 0
+		return
+	}
+	inst.failures++
+	maxFailures := c.cfg.Proxy.MaxFailures
+	if maxFailures <= 0 {
+		maxFailures = defaultProxyMaxFailures
+	}
+	if inst.failures < maxFailures || c.isClosed() {
+		return
+	}
+
+	log.Printf("浏览器 %d 的代理 %s 连续导航失败 %d 次, 更换代理", inst.id, inst.proxy, inst.failures)
+	c.proxyPool.Evict(inst.proxy)
+	if err := c.shutdown(inst); err != nil {
+		log.Printf("关闭浏览器 %d 失败: %v", inst.id, err)
+	}
+	if err := c.launchWithProxy(inst); err != nil {
+		log.Printf("重启浏览器 %d 失败: %v", inst.id, err)
+	}
+}
@@ -2,10 +2,34 @@ package parallel
 
 import (
 	"context"
+	"crawleragent-v2/internal/config"
 	"crawleragent-v2/param"
+	"crawleragent-v2/types"
+	"fmt"
 )
 
 type ParallelCrawler interface {
-	Close()
-	Crawl(ctx context.Context, params []*param.ParallelCrawlerParam) error
+	// Close 停止接收新任务并等待执行中的任务完成,ctx 结束后取消剩余任务,然后关闭所有浏览器
+	Close(ctx context.Context) error
+	// Crawl 返回的报告与 params 一一对应,即使返回错误也会包含所有任务的报告
+	Crawl(ctx context.Context, params []*param.ParallelCrawlerParam) ([]*types.CrawlReport, error)
+}
+
+const (
+	// PoolModeBrowser 每个 worker 独占一个浏览器进程
+	PoolModeBrowser = "browser"
+	// PoolModePage 所有 worker 共用一个浏览器,每个任务使用一个新的隐身上下文
+	PoolModePage = "page"
+)
+
+// InitParallelCrawler 根据 rod.pool_mode 创建爬虫,poolSize 是浏览器数量或页面数量
+func InitParallelCrawler(cfg *config.Config, poolSize int) (ParallelCrawler, error) {
+	switch cfg.Rod.PoolMode {
+	case "", PoolModeBrowser:
+		return InitBrowserPoolCrawler(cfg, poolSize)
+	case PoolModePage:
+		return InitPagePoolCrawler(cfg, poolSize)
+	default:
+		return nil, fmt.Errorf("未知的 pool_mode: %s", cfg.Rod.PoolMode)
+	}
 }
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package parallel
+
+import (
+	"os"
+	"syscall"
+)
+
+// processAlive 通过发送 0 信号检查进程是否存在
+func processAlive(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return process.Signal(syscall.Signal(0))
+}
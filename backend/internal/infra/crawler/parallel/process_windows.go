@@ -0,0 +1,14 @@
+//go:build windows
+
+package parallel
+
+import "os"
+
+// processAlive 在 Windows 上打开进程句柄失败时认为进程已退出
+func processAlive(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return process.Release()
+}
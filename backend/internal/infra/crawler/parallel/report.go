@@ -0,0 +1,91 @@
+package parallel
+
+import (
+	"context"
+	"crawleragent-v2/internal/infra/crawler/challenge"
+	"crawleragent-v2/internal/infra/crawler/robots"
+	"crawleragent-v2/param"
+	"crawleragent-v2/types"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// taskReport 在任务运行期间收集报告数据,监听器会在其他goroutine中写入
+type taskReport struct {
+	mu     sync.Mutex
+	report *types.CrawlReport
+}
+
+func newTaskReport(url string, workerID int) *taskReport {
+	return &taskReport{
+		report: &types.CrawlReport{
+			URL:       url,
+			WorkerID:  workerID,
+			StartTime: time.Now(),
+			Responses: make(map[string]int),
+		},
+	}
+}
+
+func (r *taskReport) addProcessError(urlPattern, url, stage string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report.ProcessErrors = append(r.report.ProcessErrors, types.ProcessError{
+		URLPattern: urlPattern,
+		URL:        url,
+		Stage:      stage,
+		Error:      err.Error(),
+		Time:       time.Now(),
+	})
+}
+
+// finish 填写结束时间和状态,返回最终报告
+func (r *taskReport) finish(err error) *types.CrawlReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report.EndTime = time.Now()
+	if errors.Is(err, robots.ErrDisallowed) {
+		r.report.Status = types.TaskBlocked
+		r.report.Error = err.Error()
+	} else if errors.Is(err, challenge.ErrChallenged) {
+		r.report.Status = types.TaskChallenged
+		r.report.Error = err.Error()
+	} else if err != nil {
+		r.report.Status = types.TaskFailed
+		r.report.Error = err.Error()
+	} else {
+		r.report.Status = types.TaskSucceeded
+	}
+	return r.report
+}
+
+// processErrorHandler 在 hijack 的goroutine中按 ParallelNetworkConfig.OnError 处理错误,
+// 终止类策略通过取消 ctx 通知 worker
+type processErrorHandler struct {
+	report     *taskReport
+	cancelTask context.CancelCauseFunc
+	cancelRun  context.CancelCauseFunc
+}
+
+func (h *processErrorHandler) handle(networkConfig *param.ParallelNetworkConfig, url, stage string, err error) {
+	log.Printf("%s: %v", url, err)
+	policy := networkConfig.OnError
+	if policy == "" {
+		policy = param.ErrorPolicyCollect
+	}
+	if policy == param.ErrorPolicyIgnore {
+		return
+	}
+	h.report.addProcessError(networkConfig.URLPattern, url, stage, err)
+	switch policy {
+	case param.ErrorPolicyAbortTask:
+		h.cancelTask(fmt.Errorf("%s 触发 abort_task: %v", networkConfig.URLPattern, err))
+	case param.ErrorPolicyAbortRun:
+		err = fmt.Errorf("%s 触发 abort_run: %v", networkConfig.URLPattern, err)
+		h.cancelTask(err)
+		h.cancelRun(err)
+	}
+}
@@ -0,0 +1,90 @@
+package parallel
+
+import (
+	"context"
+	"crawleragent-v2/internal/config"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/stealth"
+)
+
+// InitPagePoolCrawler 创建 page 模式的爬虫: 只启动一个浏览器进程,
+// 最多 pageCount 个任务同时执行,每个任务使用一个新的隐身上下文中的页面,
+// 任务结束后关闭上下文,任务之间不共享 cookie、存储和注入的脚本,
+// 适合内存有限的服务器。代理只在启动时分配,不会因导航失败而更换
+func InitPagePoolCrawler(cfg *config.Config, pageCount int) (ParallelCrawler, error) {
+	c, err := newCrawler(cfg, pageCount)
+	if err != nil {
+		return nil, err
+	}
+
+	inst, err := newBrowserInstance(cfg, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.launchWithProxy(inst); err != nil {
+		c.shutdown(inst)
+		return nil, err
+	}
+	c.all = append(c.all, inst)
+	c.pageSlots = make(chan struct{}, pageCount)
+	c.startSupervisor()
+	return c, nil
+}
+
+// createIncognitoPage 在新的隐身上下文中打开页面,浏览器崩溃时先重启
+// 同时返回浏览器当前代理对应的 http.Client
+func (c *browserPoolCrawler) createIncognitoPage() (*rod.Page, *http.Client, error) {
+	c.pageMu.Lock()
+	defer c.pageMu.Unlock()
+
+	inst := c.all[0]
+	if err := c.ensureHealthy(inst); err != nil {
+		return nil, nil, fmt.Errorf("%v: %w", err, errBrowserUnavailable)
+	}
+	incognito, err := inst.browser.Incognito()
+	if err != nil {
+		return nil, nil, fmt.Errorf("创建隐身上下文失败: %v", err)
+	}
+	page, err := stealth.Page(incognito)
+	if err != nil {
+		incognito.Close()
+		return nil, nil, fmt.Errorf("获取页面失败: %v", err)
+	}
+	return page, inst.client, nil
+}
+
+// closeIncognitoPage 关闭页面所在的隐身上下文,上下文中的页面随之关闭
+func closeIncognitoPage(page *rod.Page) {
+	if err := page.Browser().Close(); err != nil {
+		log.Printf("关闭隐身上下文失败: %v", err)
+	}
+}
+
+// acquireIncognitoPage 占用一个页面名额并在新的隐身上下文中打开页面,归还时关闭上下文并释放名额
+func (c *browserPoolCrawler) acquireIncognitoPage(ctx context.Context, workerID int) (*pageLease, error) {
+	select {
+	case c.pageSlots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("获取页面失败: %v", ctx.Err())
+	}
+	page, client, err := c.createIncognitoPage()
+	if err != nil {
+		<-c.pageSlots
+		return nil, err
+	}
+	return &pageLease{
+		page:   page,
+		slot:   workerID,
+		hijack: page.HijackRequests,
+		client: client,
+		release: func(bool, error) {
+			closeIncognitoPage(page)
+			log.Printf("Worker %d 关闭隐身上下文", workerID)
+			<-c.pageSlots
+		},
+	}, nil
+}
@@ -4,8 +4,19 @@ import (
 	"context"
 	"crawleragent-v2/internal/config"
 	"crawleragent-v2/internal/infra/crawler"
+	"crawleragent-v2/internal/infra/crawler/action"
+	"crawleragent-v2/internal/infra/crawler/artifact"
+	"crawleragent-v2/internal/infra/crawler/block"
+	"crawleragent-v2/internal/infra/crawler/challenge"
+	"crawleragent-v2/internal/infra/crawler/fingerprint"
+	"crawleragent-v2/internal/infra/crawler/proxy"
+	"crawleragent-v2/internal/infra/crawler/ratelimit"
+	"crawleragent-v2/internal/infra/crawler/robots"
+	"crawleragent-v2/internal/infra/crawler/session"
 	"crawleragent-v2/param"
 	"crawleragent-v2/types"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -14,168 +25,421 @@ import (
 	"time"
 
 	"github.com/go-rod/rod"
-	"github.com/go-rod/rod/lib/proto"
 	"github.com/go-rod/stealth"
 )
 
 type browserPoolCrawler struct {
-	browserPool   rod.Pool[rod.Browser]
-	createBrowser func() (*rod.Browser, error)
-	controlURLCh  chan string
+	cfg *config.Config
+	// concurrency 是同时执行的任务数
+	concurrency int
+	// instances 是空闲的浏览器实例,worker 执行任务时取出,结束后放回
+	instances chan *browserInstance
+	all       []*browserInstance
+	// pageSlots 不为空时为 page 模式,所有任务共用 all[0] 一个浏览器,见 rod_page_pool.go
+	pageSlots chan struct{}
+	// pageMu 保证 page 模式下共用的浏览器只被重启一次
+	pageMu sync.Mutex
+	// proxyPool 为空时不使用代理
+	proxyPool proxy.Pool
+	executor  action.Executor
+	// robots 为空时不检查 robots.txt
+	robots robots.Robots
+	// limiter 按 config.yaml 和任务携带的配置限流,没有配置的host不限流
+	limiter ratelimit.HostLimiter
+	// sessions 按路径缓存已加载的会话文件,值为 *cachedSession
+	sessions sync.Map
+	// blockers 按拦截策略的 JSON 缓存已创建的 Blocker
+	blockers sync.Map
+	// fingerprints 为空时不模拟指纹
+	fingerprints fingerprint.Rotator
+	// detector 为空时不检测反爬验证, solver 为空时遇到验证直接标记任务
+	detector challenge.Detector
+	solver   challenge.Solver
+	// stopSupervisor 关闭后健康检查协程退出,退出完成后关闭 supervisorDone
+	stopSupervisor chan struct{}
+	supervisorDone chan struct{}
+
+	// mu 保护 closed 和 inflight 的计数,避免 Close 等待时有新的 Crawl 加入
+	mu     sync.Mutex
+	closed bool
+	// closing 关闭后 worker 不再领取新任务
+	closing  chan struct{}
+	inflight sync.WaitGroup
+	// abortCtx 在关闭等待超时后被取消,用于终止仍在执行的任务
+	abortCtx context.Context
+	abortAll context.CancelCauseFunc
 }
 
-func InitBrowserPoolCrawler(cfg *config.Config, browserPoolSize int) (ParallelCrawler, error) {
-	controlURLCh := make(chan string, browserPoolSize)
-	for instanceID := range browserPoolSize {
+// ErrClosed 表示浏览器池已关闭,任务不会再被执行
+var ErrClosed = errors.New("浏览器池已关闭")
+
+// abortGracePeriod 是取消剩余任务后等待其退出的时间,超时后直接关闭浏览器
+const abortGracePeriod = 5 * time.Second
+
+// pageLease 是任务占用的页面,任务结束后调用 release 归还
+type pageLease struct {
+	page *rod.Page
+	// slot 用于按实例分配指纹,browser 模式为浏览器实例编号,page 模式为 worker 编号
+	slot int
+	// hijack 创建拦截该任务请求的路由器
+	hijack func() *rod.HijackRouter
+	// client 用于重新发送拦截到的请求,与页面使用同一个代理
+	client *http.Client
+	// release 关闭或归还页面,navigated 和 navErr 用于统计代理的导航失败次数
+	release func(navigated bool, navErr error)
+}
+
+// newCrawler 创建两种模式共用的爬虫结构,不启动浏览器
+func newCrawler(cfg *config.Config, concurrency int) (*browserPoolCrawler, error) {
+	proxyPool, err := proxy.InitProxyPool(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("初始化代理池失败: %v", err)
+	}
 
-		instanceDataDir := fmt.Sprintf("%s/instance_%d", cfg.Rod.UserDataDir, instanceID)
-		err := os.MkdirAll(instanceDataDir, 0755)
+	c := &browserPoolCrawler{
+		cfg:         cfg,
+		concurrency: concurrency,
+		instances:   make(chan *browserInstance, concurrency),
+		proxyPool:   proxyPool,
+		executor:    action.InitExecutor(),
+		limiter:     ratelimit.InitHostLimiter(cfg),
+		closing:     make(chan struct{}),
+	}
+	c.abortCtx, c.abortAll = context.WithCancelCause(context.Background())
+	if cfg.Robots.Enabled {
+		c.robots = robots.InitRobots(cfg.Robots.UserAgent, cfg.Robots.IgnoreHosts, cfg.Robots.CacheTTL)
+	}
+	c.fingerprints, err = fingerprint.InitRotator(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("初始化指纹失败: %v", err)
+	}
+	if c.detector = challenge.InitDetector(cfg); c.detector != nil {
+		c.solver, err = challenge.InitSolver(cfg, c.detector)
 		if err != nil {
-			return nil, fmt.Errorf("创建实例数据目录失败: %v", err)
+			return nil, fmt.Errorf("初始化验证处理器失败: %v", err)
 		}
+	}
+	return c, nil
+}
+
+// InitBrowserPoolCrawler 创建 browser 模式的爬虫,每个 worker 独占一个浏览器进程
+func InitBrowserPoolCrawler(cfg *config.Config, browserPoolSize int) (ParallelCrawler, error) {
+	c, err := newCrawler(cfg, browserPoolSize)
+	if err != nil {
+		return nil, err
+	}
 
-		url := crawler.CreateLauncher(cfg.Rod.UserMode,
-			crawler.WithBin(cfg.Rod.Bin),
-			crawler.WithUserDataDir(instanceDataDir),
-			crawler.WithHeadless(cfg.Rod.Headless),
-			crawler.WithDisableBlinkFeatures(cfg.Rod.DisableBlinkFeatures),
-			crawler.WithIncognito(cfg.Rod.Incognito),
-			crawler.WithDisableDevShmUsage(cfg.Rod.DisableDevShmUsage),
-			crawler.WithNoSandbox(cfg.Rod.NoSandbox),
-			crawler.WithUserAgent(cfg.Rod.UserAgent),
-			crawler.WithLeakless(cfg.Rod.Leakless),
-			crawler.WithDisableBackgroundNetworking(cfg.Rod.DisableBackgroundNetworking),
-			crawler.WithDisableBackgroundTimerThrottling(cfg.Rod.DisableBackgroundTimerThrottling),
-			crawler.WithRemoteDebuggingPort(cfg.Rod.BasicRemoteDebuggingPort+instanceID),
-		)
-		urlStr, err := url.Launch()
+	for instanceID := range browserPoolSize {
+		inst, err := newBrowserInstance(cfg, instanceID)
 		if err != nil {
-			return nil, fmt.Errorf("启动浏览器失败: %v", err)
+			c.Close(context.Background())
+			return nil, err
 		}
-
-		log.Printf("浏览器可以连接的URL: %s", urlStr)
-		controlURLCh <- urlStr
-	}
-	close(controlURLCh)
-	// 创建页面池
-	BrowserPool := rod.NewBrowserPool(browserPoolSize)
-
-	createBrowser := func() (*rod.Browser, error) {
-		// 从 controlURLCh 中获取 URL
-		urlStr := <-controlURLCh
-		browser := rod.
-			New().
-			ControlURL(urlStr).
-			Trace(cfg.Rod.Trace) // 开启 CDP 通信追踪（日志会输出请求/响应）
-		if err := browser.Connect(); err != nil {
-			return nil, fmt.Errorf("连接浏览器失败: %v", err)
+		if err := c.launchWithProxy(inst); err != nil {
+			c.shutdown(inst)
+			c.Close(context.Background())
+			return nil, err
 		}
-		return browser, nil
+		c.all = append(c.all, inst)
+		c.instances <- inst
 	}
-
-	return &browserPoolCrawler{
-		browserPool:   BrowserPool,
-		createBrowser: createBrowser,
-		controlURLCh:  controlURLCh,
-	}, nil
+	c.startSupervisor()
+	return c, nil
 }
 
-func (c *browserPoolCrawler) Close() {
-	log.Printf("开始关闭，停止接收新请求...")
+func (c *browserPoolCrawler) Close(ctx context.Context) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.closing)
+	c.mu.Unlock()
+	log.Printf("开始关闭，停止接收新任务...")
+
+	// 等待执行中的任务完成,包括已拦截但还在处理的网络响应
+	done := make(chan struct{})
+	go func() {
+		c.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		log.Printf("执行中的任务已全部完成")
+	case <-ctx.Done():
+		log.Printf("等待执行中的任务超时, 取消剩余任务")
+		c.abortAll(ErrClosed)
+		select {
+		case <-done:
+		case <-time.After(abortGracePeriod):
+			log.Printf("部分任务未能在 %v 内退出, 直接关闭浏览器", abortGracePeriod)
+		}
+	}
 
-	// 2. 等待一段时间让正在进行的请求完成
-	time.Sleep(3 * time.Second) // 可以根据实际情况调整
+	if c.stopSupervisor != nil {
+		close(c.stopSupervisor)
+		<-c.supervisorDone
+	}
+	log.Printf("关闭 %d 个浏览器连接", len(c.all))
+	var errs []error
+	for _, inst := range c.all {
+		if err := c.shutdown(inst); err != nil {
+			errs = append(errs, fmt.Errorf("关闭浏览器 %d 失败: %v", inst.id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
 
-	log.Printf("关闭 %d 个浏览器连接", len(c.browserPool))
-	c.browserPool.Cleanup(func(b *rod.Browser) { b.MustClose() })
+func (c *browserPoolCrawler) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
 }
 
-func (c *browserPoolCrawler) Crawl(ctx context.Context, params []*param.ParallelCrawlerParam) error {
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
+func (c *browserPoolCrawler) Crawl(ctx context.Context, params []*param.ParallelCrawlerParam) ([]*types.CrawlReport, error) {
+	reports := make([]*types.CrawlReport, len(params))
 
-	paramCh := make(chan *param.ParallelCrawlerParam, len(params))
-	for _, op := range params {
-		paramCh <- op
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		for i := range params {
+			reports[i] = newTaskReport(params[i].URL, -1).finish(fmt.Errorf("任务未执行: %w", ErrClosed))
+		}
+		return reports, ErrClosed
 	}
-	close(paramCh)
-
-	errCh := make(chan error, max(len(paramCh), len(c.browserPool)))
+	c.inflight.Add(1)
+	c.mu.Unlock()
+	defer c.inflight.Done()
+
+	// abort_run 策略通过 cancelRun 终止整个爬取过程,关闭超时后也会终止
+	ctx, cancelRun := context.WithCancelCause(ctx)
+	defer cancelRun(nil)
+	stopAbort := context.AfterFunc(c.abortCtx, func() {
+		cancelRun(context.Cause(c.abortCtx))
+	})
+	defer stopAbort()
+
+	// 按下标分发任务,报告与 params 一一对应
+	indexCh := make(chan int, len(params))
+	for i := range params {
+		indexCh <- i
+	}
+	close(indexCh)
 
 	wg := sync.WaitGroup{}
-	for i := range min(len(c.browserPool), len(params)) {
+	for i := range min(c.concurrency, len(params)) {
 		wg.Add(1)
 		go func(ctx context.Context, workerID int) {
 			defer wg.Done()
 			for {
+				// 关闭后不再领取新任务,执行中的任务不受影响
+				select {
+				case <-c.closing:
+					log.Printf("worker %d 浏览器池关闭，退出", workerID)
+					return
+				default:
+				}
 				select {
 				case <-ctx.Done(): // 主动监听 ctx 取消
 					log.Printf("worker %d 取消执行，退出", workerID)
 					return
-				case param, ok := <-paramCh: // 读取任务
+				case <-c.closing:
+					log.Printf("worker %d 浏览器池关闭，退出", workerID)
+					return
+				case index, ok := <-indexCh: // 读取任务
 					if !ok { // 通道关闭则退出
 						return
 					}
-					c.processParam(ctx, workerID, errCh, param)
+					reports[index] = c.processParam(ctx, cancelRun, workerID, params[index])
 				}
 			}
 		}(ctx, i)
 	}
 	wg.Wait()
 
-	close(errCh)
 	// 收集错误
 	var errs []error
-	for err := range errCh {
-		errs = append(errs, err)
+	for i, report := range reports {
+		if report == nil {
+			// ctx 取消或浏览器池关闭后未被执行的任务
+			cause := context.Cause(ctx)
+			if cause == nil {
+				cause = ErrClosed
+			}
+			report = newTaskReport(params[i].URL, -1).finish(fmt.Errorf("任务未执行: %v", cause))
+			reports[i] = report
+		}
+		if report.Status == types.TaskBlocked {
+			log.Printf("任务 %s 被跳过: %s", report.URL, report.Error)
+		}
+		if report.Status == types.TaskFailed || report.Status == types.TaskChallenged {
+			errs = append(errs, fmt.Errorf("%s: %s", report.URL, report.Error))
+		}
 	}
 	if len(errs) > 0 {
-		return fmt.Errorf("%d errors occurred: %v", len(errs), errs)
+		return reports, fmt.Errorf("%d errors occurred: %v", len(errs), errs)
 	}
-	return nil
+	return reports, nil
 }
 
-func (c *browserPoolCrawler) processParam(ctx context.Context, workerID int, errCh chan<- error, params *param.ParallelCrawlerParam) {
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
+func (c *browserPoolCrawler) processParam(ctx context.Context, cancelRun context.CancelCauseFunc, workerID int, params *param.ParallelCrawlerParam) *types.CrawlReport {
+	report := newTaskReport(params.URL, workerID)
+	report.report.Depth = params.Depth
+	report.report.TaskID = params.TaskID
+	if report.report.TaskID == "" {
+		report.report.TaskID = param.NewTaskID()
+	}
+	ctx = param.ContextWithTask(ctx, params)
 
-	browser, err := c.browserPool.Get(c.createBrowser)
+	// abort_task 策略通过 cancelTask 终止当前任务
+	ctx, cancelTask := context.WithCancelCause(ctx)
+	defer cancelTask(nil)
+
+	handler := &processErrorHandler{
+		report:     report,
+		cancelTask: cancelTask,
+		cancelRun:  cancelRun,
+	}
+	err := c.runTask(ctx, workerID, params, report, handler)
+	// 因错误策略被终止时,以终止原因作为任务错误,而不是 context canceled
+	if ctx.Err() != nil {
+		if cause := context.Cause(ctx); cause != nil {
+			err = cause
+		}
+	}
+	return report.finish(err)
+}
+
+func (c *browserPoolCrawler) runTask(ctx context.Context, workerID int, params *param.ParallelCrawlerParam, report *taskReport, handler *processErrorHandler) error {
+	// 在占用浏览器之前检查 robots.txt 并按 Crawl-delay 等待
+	if c.robots != nil && !params.IgnoreRobots {
+		if err := c.robots.Wait(ctx, params.URL); err != nil {
+			return err
+		}
+	}
+	release, err := c.limiter.Acquire(ctx, params.URL, hostLimits(params.RateLimits))
 	if err != nil {
-		errCh <- fmt.Errorf("获取浏览器失败: %v", err)
-		return
+		return fmt.Errorf("等待限流失败: %v", err)
 	}
-	defer func() {
-		log.Printf("将 browser %d 返回池，处理的URL模式: %s 等...", workerID, params.NetworkConfigs[0].URLPattern)
-		c.browserPool.Put(browser)
-	}()
+	defer release()
 
-	page, err := stealth.Page(browser)
+	var lease *pageLease
+	if c.pageSlots != nil {
+		lease, err = c.acquireIncognitoPage(ctx, workerID)
+	} else {
+		lease, err = c.acquireBrowserPage(ctx, workerID, params.URL)
+	}
 	if err != nil {
-		errCh <- fmt.Errorf("获取页面失败: %v", err)
-		return
+		return err
 	}
+	var (
+		navigated bool
+		navErr    error
+	)
 	defer func() {
-		log.Printf("Worker %d 页面关闭", workerID)
-		page.MustClose()
+		lease.release(navigated, navErr)
 	}()
+	page := lease.page
+
+	if c.fingerprints != nil {
+		profile := c.fingerprints.Pick(lease.slot)
+		if err := fingerprint.Apply(page, profile); err != nil {
+			return fmt.Errorf("应用指纹 %s 失败: %v", profile.Name, err)
+		}
+		report.mu.Lock()
+		report.report.Fingerprint = profile.Name
+		report.mu.Unlock()
+	}
+
+	if params.Session != "" {
+		s, err := c.loadSession(params.Session)
+		if err != nil {
+			return err
+		}
+		removeSession, err := session.Inject(page, s)
+		if err != nil {
+			return fmt.Errorf("注入会话失败: %v", err)
+		}
+		defer func() {
+			if err := removeSession(); err != nil {
+				log.Printf("Worker %d 移除会话注入脚本失败: %v", workerID, err)
+			}
+		}()
+	}
 
-	// 设置所有网络监听器
-	if params.NetworkConfigs != nil {
-		router := c.setListener(ctx, browser, params.NetworkConfigs)
+	blocker, err := c.loadBlocker(params.Block)
+	if err != nil {
+		return err
+	}
+
+	// 产物保存失败不影响任务,任务结束时(包括失败)保存页面的最终状态
+	artifacts, err := artifact.InitRecorder(c.cfg.Artifacts.Dir, report.report.TaskID, params.Artifacts)
+	if err != nil {
+		log.Printf("任务 %s 不保存产物: %v", report.report.TaskID, err)
+	}
+	if artifacts != nil {
+		defer func() {
+			if params.Artifacts.Screenshot {
+				artifacts.Screenshot(page, "final")
+			}
+			if params.Artifacts.PDF {
+				artifacts.PDF(page)
+			}
+			files := artifacts.Close()
+			report.mu.Lock()
+			report.report.ArtifactDir = artifacts.Dir()
+			report.report.Artifacts = files
+			report.mu.Unlock()
+		}()
+	}
+
+	// 设置所有网络监听器,拦截规则在监听规则之后添加
+	recorder := action.InitResponseRecorder()
+	if params.NetworkConfigs != nil || blocker != nil {
+		// pending 记录正在处理的拦截请求,路由器关闭后等待它们处理完成再关闭页面
+		pending := &pendingHijacks{}
+		router := c.setListener(ctx, lease.hijack(), lease.client, params.NetworkConfigs, recorder, artifacts, handler, pending)
+		if blocker != nil {
+			if err := blocker.Apply(router); err != nil {
+				router.Stop()
+				return err
+			}
+		}
 		go func() {
 			router.Run()
 			log.Printf("Worker %d 路由器停止运行", workerID)
 		}()
 		defer func() {
 			log.Printf("Worker %d 路由器关闭", workerID)
-			router.Stop()
+			if err := router.Stop(); err != nil {
+				log.Printf("Worker %d 关闭路由器失败: %v", workerID, err)
+			}
+			pending.closeAndWait()
 		}()
 	}
+	// 页面关闭前记录最终URL和响应数量
+	defer func() {
+		report.mu.Lock()
+		defer report.mu.Unlock()
+		if info, err := page.Info(); err == nil {
+			report.report.FinalURL = info.URL
+		}
+		for _, networkConfig := range params.NetworkConfigs {
+			report.report.Responses[networkConfig.URLPattern] = recorder.Count(networkConfig.URLPattern)
+		}
+	}()
 
-	err = c.navigateURL(page, workerID, params.URL)
+	err = c.navigateURL(ctx, page, workerID, params.URL)
+	navigated, navErr = true, err
+	if artifacts != nil && params.Artifacts.Screenshot {
+		artifacts.Screenshot(page, "navigated")
+	}
 	if err != nil {
-		errCh <- fmt.Errorf("处理URL失败: %v", err)
-		return
+		return fmt.Errorf("处理URL失败: %v", err)
+	}
+	if err := c.handleChallenge(ctx, workerID, page, report, "navigate"); err != nil {
+		return err
 	}
 
 	var waitIncludes []string
@@ -183,148 +447,234 @@ func (c *browserPoolCrawler) processParam(ctx context.Context, workerID int, err
 		waitIncludes = append(waitIncludes, networkConfig.URLPattern)
 	}
 
-	for _, action := range params.Actions {
-		err := c.executeAction(ctx, page, action, waitIncludes, nil)
-		if err != nil {
-			errCh <- fmt.Errorf("执行操作失败: %v", err)
-			return
+	opts := &action.ExecuteOptions{
+		WaitIncludes:   waitIncludes,
+		Responses:      recorder,
+		BeforeNavigate: c.beforeNavigate(params),
+		Humanize:       params.Humanize,
+	}
+	if artifacts != nil && params.Artifacts.ActionScreenshots {
+		opts.AfterAction = func(outcome types.ActionOutcome) error {
+			artifacts.Screenshot(page, outcome.Path)
+			return nil
 		}
 	}
+	if c.detector != nil {
+		screenshot := opts.AfterAction
+		opts.AfterAction = func(outcome types.ActionOutcome) error {
+			if screenshot != nil {
+				screenshot(outcome)
+			}
+			return c.handleChallenge(ctx, workerID, page, report, outcome.Path)
+		}
+	}
+	outcomes, err := c.executor.ExecuteActions(ctx, page, params.Actions, opts)
+	report.mu.Lock()
+	report.report.Actions = outcomes
+	report.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("执行操作失败: %w", err)
+	}
+	return nil
 }
 
-func (c *browserPoolCrawler) navigateURL(page *rod.Page, workerID int, url string) error {
-	// 导航到指定URL
-	fmt.Printf("Worker %d 处理: %s\n", workerID, url)
+// acquireBrowserPage 取出一个空闲的浏览器并打开页面,归还时关闭页面并放回浏览器
+func (c *browserPoolCrawler) acquireBrowserPage(ctx context.Context, workerID int, url string) (*pageLease, error) {
+	var inst *browserInstance
+	select {
+	case inst = <-c.instances:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("获取浏览器失败: %v", ctx.Err())
+	}
+	putBack := func() {
+		log.Printf("将 browser %d 返回池，处理的URL: %s", inst.id, url)
+		c.instances <- inst
+	}
+	// 浏览器崩溃后在这里重启,重启失败则任务失败,下一个任务取用时再次尝试
+	if err := c.ensureHealthy(inst); err != nil {
+		putBack()
+		return nil, fmt.Errorf("%v: %w", err, errBrowserUnavailable)
+	}
+	browser := inst.browser
 
-	err := page.Navigate(url)
+	page, err := stealth.Page(browser)
 	if err != nil {
-		return fmt.Errorf("导航失败: %v", err)
+		putBack()
+		return nil, fmt.Errorf("获取页面失败: %v", err)
 	}
+	return &pageLease{
+		page:   page,
+		slot:   inst.id,
+		hijack: browser.HijackRequests,
+		client: inst.client,
+		release: func(navigated bool, navErr error) {
+			log.Printf("Worker %d 页面关闭", workerID)
+			if err := page.Close(); err != nil {
+				log.Printf("Worker %d 关闭页面失败: %v", workerID, err)
+			}
+			// 页面已关闭,此时可以安全地更换代理并重启浏览器
+			if navigated {
+				c.recordNavigation(inst, navErr)
+			}
+			putBack()
+		},
+	}, nil
+}
 
-	page.MustWaitStable()
-	time.Sleep(2 * time.Second)
+// loadBlocker 创建拦截策略对应的 Blocker,相同的策略只创建一次
+func (c *browserPoolCrawler) loadBlocker(policy *param.BlockPolicy) (block.Blocker, error) {
+	if policy == nil {
+		return nil, nil
+	}
+	key, err := json.Marshal(policy)
+	if err != nil {
+		return nil, fmt.Errorf("序列化拦截策略失败: %v", err)
+	}
+	if cached, ok := c.blockers.Load(string(key)); ok {
+		blocker, _ := cached.(block.Blocker)
+		return blocker, nil
+	}
+	blocker, err := block.InitBlocker(policy)
+	if err != nil {
+		return nil, fmt.Errorf("创建拦截策略失败: %v", err)
+	}
+	c.blockers.Store(string(key), blocker)
+	return blocker, nil
+}
 
-	return nil
+// cachedSession 是缓存的会话及读取时文件的修改时间
+type cachedSession struct {
+	session *session.Session
+	modTime time.Time
 }
 
-func (c *browserPoolCrawler) executeAction(ctx context.Context, page *rod.Page, action param.Action, waitIncludes, waitExcludes []string) error {
-	err := action.Validate()
+// loadSession 加载会话文件,文件修改时间变化时重新读取,如重新导出会话之后
+func (c *browserPoolCrawler) loadSession(path string) (*session.Session, error) {
+	info, err := os.Stat(path)
 	if err != nil {
-		return fmt.Errorf("操作验证失败: %v", err)
+		c.sessions.Delete(path)
+		return nil, fmt.Errorf("读取会话文件失败: %v", err)
 	}
-	switch a := action.(type) {
-	case *param.ClickAction:
-		element, err := page.Element(a.Selector)
-		if err != nil {
-			return fmt.Errorf("点击操作失败: %v", err)
+	if cached, ok := c.sessions.Load(path); ok {
+		if entry := cached.(*cachedSession); entry.modTime.Equal(info.ModTime()) {
+			return entry.session, nil
 		}
-		err = element.Click(proto.InputMouseButtonLeft, 1)
-		if err != nil {
-			return fmt.Errorf("点击操作失败: %v", err)
-		}
-		page.WaitRequestIdle(500*time.Millisecond, waitIncludes, waitExcludes, nil)
-		time.Sleep(a.Delay)
-	case *param.ClickXAction:
-		element, err := page.ElementX(a.Selector)
-		if err != nil {
-			return fmt.Errorf("点击X操作失败: %v", err)
-		}
-		err = element.Click(proto.InputMouseButtonLeft, 1)
-		if err != nil {
-			return fmt.Errorf("点击X操作失败: %v", err)
+	}
+	s, err := session.LoadSession(path)
+	if err != nil {
+		c.sessions.Delete(path)
+		return nil, err
+	}
+	c.sessions.Store(path, &cachedSession{session: s, modTime: info.ModTime()})
+	return s, nil
+}
+
+// beforeNavigate 返回操作导航前的检查,与任务URL一样检查 robots.txt 并按host限速
+// 页面名额在任务开始时已经占用,这里只消耗速率令牌
+func (c *browserPoolCrawler) beforeNavigate(params *param.ParallelCrawlerParam) func(ctx context.Context, url string) error {
+	checkRobots := c.robots != nil && !params.IgnoreRobots
+	limits := hostLimits(params.RateLimits)
+	return func(ctx context.Context, url string) error {
+		if checkRobots {
+			if err := c.robots.Wait(ctx, url); err != nil {
+				return err
+			}
 		}
-		page.WaitRequestIdle(500*time.Millisecond, waitIncludes, waitExcludes, nil)
-		time.Sleep(a.Delay)
-	case *param.ScrollAction:
-		_, err = page.Eval(
-			`
-					(scrollY) => {
-						window.scrollBy({
-							top: scrollY,
-							behavior: 'smooth'
-						});
-					}
-				`, a.ScrollY)
-		page.WaitRequestIdle(500*time.Millisecond, waitIncludes, waitExcludes, nil)
-		time.Sleep(a.Delay)
-	case *param.JavaScriptAction:
-		// 执行JavaScript操作
-		err = c.executeJavaScript(ctx, page, a)
-		if err != nil {
-			return fmt.Errorf("执行JavaScript操作失败: %v", err)
+		if err := c.limiter.Wait(ctx, url, limits); err != nil {
+			return fmt.Errorf("等待限流失败: %v", err)
 		}
-		page.WaitRequestIdle(500*time.Millisecond, waitIncludes, waitExcludes, nil)
-		time.Sleep(a.Delay)
-	default:
-		return fmt.Errorf("未知操作类型: %T", a)
+		return nil
 	}
-	return nil
 }
 
-func (c *browserPoolCrawler) setListener(ctx context.Context, browser *rod.Browser, networkConfigs []*param.ParallelNetworkConfig) *rod.HijackRouter {
-	router := browser.HijackRequests()
+func hostLimits(limits []*param.HostLimit) []config.HostLimit {
+	result := make([]config.HostLimit, 0, len(limits))
+	for _, limit := range limits {
+		result = append(result, config.HostLimit{
+			Host:               limit.Host,
+			RequestsPerSecond:  limit.RequestsPerSecond,
+			Burst:              limit.Burst,
+			MaxConcurrentPages: limit.MaxConcurrentPages,
+		})
+	}
+	return result
+}
+
+func (c *browserPoolCrawler) navigateURL(ctx context.Context, page *rod.Page, workerID int, url string) error {
+	// 导航到指定URL
+	fmt.Printf("Worker %d 处理: %s\n", workerID, url)
+	return crawler.NavigateAndSettle(ctx, page, url)
+}
+
+// pendingHijacks 记录正在处理的拦截请求,拦截回调在独立的协程中执行,
+// 可能在路由器关闭后才开始,关闭后不再记录新的请求,避免与等待同时进行
+type pendingHijacks struct {
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// add 记录一个开始处理的请求,已关闭时返回 false,调用方应直接返回
+func (p *pendingHijacks) add() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return false
+	}
+	p.wg.Add(1)
+	return true
+}
+
+func (p *pendingHijacks) done() {
+	p.wg.Done()
+}
+
+// closeAndWait 停止记录新的请求并等待已记录的请求处理完成
+func (p *pendingHijacks) closeAndWait() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+func (c *browserPoolCrawler) setListener(ctx context.Context, router *rod.HijackRouter, client *http.Client, networkConfigs []*param.ParallelNetworkConfig, recorder action.ResponseRecorder, artifacts artifact.Recorder, handler *processErrorHandler, pending *pendingHijacks) *rod.HijackRouter {
 	for _, networkConfig := range networkConfigs {
 		router.MustAdd(networkConfig.URLPattern, func(hijack *rod.Hijack) {
+			if !pending.add() {
+				return
+			}
+			defer pending.done()
 			select {
 			case <-ctx.Done():
 				return
 			default:
 			}
-			err := hijack.LoadResponse(http.DefaultClient, true)
+			started := time.Now()
+			err := hijack.LoadResponse(client, true)
 			if err != nil {
-				log.Printf("加载响应失败: %v", err)
+				handler.handle(networkConfig, hijack.Request.URL().String(), "load", fmt.Errorf("加载响应失败: %v", err))
 				return
 			}
-			body := hijack.Response.Body()
+			resp := &types.NetworkResponse{
+				Url:        hijack.Request.URL().String(),
+				UrlPattern: networkConfig.URLPattern,
+				Body:       hijack.Response.Body(),
+			}
+			recorder.Record(resp)
+			if artifacts != nil {
+				artifacts.RecordRequest(hijack, started)
+			}
 
 			if networkConfig.ProcessFunc == nil {
 				return
 			}
 
-			err = networkConfig.ProcessFunc(ctx,
-				&types.NetworkResponse{
-					Url:        hijack.Request.URL().String(),
-					UrlPattern: networkConfig.URLPattern,
-					Body:       body,
-				},
-			)
+			err = networkConfig.ProcessFunc(ctx, resp)
 			if err != nil {
-				log.Printf("处理网络响应失败: %v", err)
+				handler.handle(networkConfig, resp.Url, "process", fmt.Errorf("处理网络响应失败: %v", err))
 				return
 			}
 		})
 	}
 	return router
 }
-
-func (c *browserPoolCrawler) executeJavaScript(ctx context.Context, page *rod.Page, action *param.JavaScriptAction) error {
-	result, err := page.Eval(action.JavaScript, action.JavaScriptArgs...)
-	if err != nil {
-		return fmt.Errorf("执行JavaScript失败: %v", err)
-	}
-	//log.Printf("执行JavaScript: %s", javascript)
-	jsonResult, err := result.Value.MarshalJSON()
-	if err != nil {
-		return fmt.Errorf("JSON序列化失败: %v", err)
-	}
-	info, err := page.Info()
-	if err != nil {
-		return fmt.Errorf("获取页面信息失败: %v", err)
-	}
-	//log.Printf("执行JavaScript成功: %d, %s", len(jsonResult), string(jsonResult))
-
-	if action.ProcessFunc == nil {
-		return nil
-	}
-
-	err = action.ProcessFunc(ctx,
-		&types.HtmlContent{
-			Url:     info.URL,
-			Content: jsonResult,
-		},
-	)
-	if err != nil {
-		return fmt.Errorf("处理JavaScript内容失败: %v", err)
-	}
-	return nil
-}
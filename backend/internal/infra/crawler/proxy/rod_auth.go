@@ -0,0 +1,119 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// ErrProxyUnavailable 表示无法通过代理访问或代理认证失败,调用方应淘汰该代理
+var ErrProxyUnavailable = errors.New("代理不可用")
+
+// sessionClient 把 CDP 调用发送到浏览器上的独立会话
+type sessionClient struct {
+	browser   *rod.Browser
+	sessionID proto.TargetSessionID
+}
+
+func (c *sessionClient) Call(ctx context.Context, _, method string, params interface{}) ([]byte, error) {
+	return c.browser.Call(ctx, string(c.sessionID), method, params)
+}
+
+// HandleAuth 为需要认证的代理持续回答认证,返回的 stop 用于停止回答,关闭浏览器前调用
+// rod 的 HandleAuth 只回答一次认证,浏览器与代理重新建立连接时再次认证会失败,
+// 这里在独立的浏览器会话中开启 Fetch 域,覆盖之后创建的所有页面和隐身上下文。
+// 会话的 Fetch 配置与 HijackRouter 互不影响,HijackRouter 不处理认证,认证会交给这里回答
+func HandleAuth(browser *rod.Browser, p *Proxy) (stop func(), err error) {
+	if !p.NeedAuth() {
+		return func() {}, nil
+	}
+	session, err := proto.TargetAttachToBrowserTarget{}.Call(browser)
+	if err != nil {
+		return nil, fmt.Errorf("创建代理认证会话失败: %v", err)
+	}
+	client := &sessionClient{browser: browser, sessionID: session.SessionID}
+	detach := func() {
+		_ = proto.TargetDetachFromTarget{SessionID: session.SessionID}.Call(browser)
+	}
+
+	// 先订阅事件再开启 Fetch 域,避免漏掉开启后立即暂停的请求
+	ctx, cancel := context.WithCancel(browser.GetContext())
+	events := browser.Context(ctx).Event()
+	err = proto.FetchEnable{
+		Patterns:           []*proto.FetchRequestPattern{{URLPattern: "*"}},
+		HandleAuthRequests: true,
+	}.Call(client)
+	if err != nil {
+		cancel()
+		detach()
+		return nil, fmt.Errorf("开启代理认证失败: %v", err)
+	}
+	go answerAuth(client, p, events)
+
+	return func() {
+		cancel()
+		_ = proto.FetchDisable{}.Call(client)
+		detach()
+	}, nil
+}
+
+// answerAuth 放行会话中暂停的请求并回答代理认证,
+// 同一请求再次要求认证说明账号密码错误,取消认证使请求失败,避免反复重试
+func answerAuth(client *sessionClient, p *Proxy, events <-chan *rod.Message) {
+	attempted := make(map[proto.FetchRequestID]bool)
+	for msg := range events {
+		if msg.SessionID != client.sessionID {
+			continue
+		}
+		paused := &proto.FetchRequestPaused{}
+		auth := &proto.FetchAuthRequired{}
+		switch {
+		case msg.Load(paused):
+			go func() {
+				_ = proto.FetchContinueRequest{RequestID: paused.RequestID}.Call(client)
+			}()
+		case msg.Load(auth):
+			// 网站自身的认证交给浏览器默认处理
+			response := &proto.FetchAuthChallengeResponse{
+				Response: proto.FetchAuthChallengeResponseResponseDefault,
+			}
+			if auth.AuthChallenge.Source == proto.FetchAuthChallengeSourceProxy {
+				if attempted[auth.RequestID] {
+					log.Printf("代理 %s 认证失败: %s", p, auth.Request.URL)
+					response.Response = proto.FetchAuthChallengeResponseResponseCancelAuth
+				} else {
+					attempted[auth.RequestID] = true
+					response.Response = proto.FetchAuthChallengeResponseResponseProvideCredentials
+					response.Username = p.Username
+					response.Password = p.Password
+				}
+			}
+			go func() {
+				_ = proto.FetchContinueWithAuth{
+					RequestID:             auth.RequestID,
+					AuthChallengeResponse: response,
+				}.Call(client)
+			}()
+		}
+	}
+}
+
+// Check 通过代理访问 checkURL 检查代理是否可用,需要认证的代理先调用 HandleAuth
+// 无法访问或认证失败时返回的错误包含 ErrProxyUnavailable,其他错误与代理无关
+func Check(browser *rod.Browser, p *Proxy, checkURL string, timeout time.Duration) error {
+	page, err := browser.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		return fmt.Errorf("创建代理检查页面失败: %v", err)
+	}
+	defer page.Close()
+
+	if err := page.Timeout(timeout).Navigate(checkURL); err != nil {
+		return fmt.Errorf("通过代理 %s 访问 %s 失败: %v: %w", p, checkURL, err, ErrProxyUnavailable)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package proxy
+
+import (
+	"bufio"
+	"crawleragent-v2/internal/config"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+type staticPool struct {
+	mu      sync.Mutex
+	proxies []*Proxy
+	next    int
+}
+
+// InitProxyPool 从配置中的代理列表和代理文件创建代理池,没有配置代理时返回 nil
+// 代理文件每行一个代理,忽略空行和 # 开头的注释
+func InitProxyPool(cfg *config.Config) (Pool, error) {
+	raws := append([]string{}, cfg.Proxy.List...)
+	if cfg.Proxy.File != "" {
+		fileProxies, err := readProxyFile(cfg.Proxy.File)
+		if err != nil {
+			return nil, err
+		}
+		raws = append(raws, fileProxies...)
+	}
+	if len(raws) == 0 {
+		return nil, nil
+	}
+
+	proxies := make([]*Proxy, 0, len(raws))
+	for _, raw := range raws {
+		p, err := ParseProxy(raw)
+		if err != nil {
+			return nil, err
+		}
+		proxies = append(proxies, p)
+	}
+	log.Printf("加载了 %d 个代理", len(proxies))
+	return &staticPool{proxies: proxies}, nil
+}
+
+func readProxyFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取代理文件失败: %v", err)
+	}
+	defer file.Close()
+
+	var raws []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		raws = append(raws, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取代理文件失败: %v", err)
+	}
+	return raws, nil
+}
+
+func (p *staticPool) Next() (*Proxy, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.proxies) == 0 {
+		return nil, ErrNoProxy
+	}
+	proxy := p.proxies[p.next%len(p.proxies)]
+	p.next++
+	return proxy, nil
+}
+
+func (p *staticPool) Evict(target *Proxy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, proxy := range p.proxies {
+		if proxy == target {
+			p.proxies = append(p.proxies[:i], p.proxies[i+1:]...)
+			log.Printf("淘汰代理 %s, 剩余 %d 个", target, len(p.proxies))
+			return
+		}
+	}
+}
+
+func (p *staticPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.proxies)
+}
@@ -0,0 +1,176 @@
+package ratelimit
+
+import (
+	"context"
+	"crawleragent-v2/internal/config"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type hostState struct {
+	// bucket 为空表示不限速
+	bucket *tokenBucket
+	// pages 为空表示不限制并发页面数
+	pages chan struct{}
+}
+
+// stateKey 区分配置项和配置内容,任务携带不同配置时使用各自的状态,
+// 状态创建后不会删除,仍被占用的页面名额总能正确释放
+type stateKey struct {
+	// host 是命中的配置项的host,使用默认配置时为实际访问的host
+	host  string
+	limit config.HostLimit
+}
+
+type hostLimiter struct {
+	// defaultLimit 是 Host 为空的配置,适用于没有单独配置的host
+	defaultLimit *config.HostLimit
+	// limits 按host保存单独的配置,后出现的配置覆盖前面的,创建后不再修改
+	limits map[string]config.HostLimit
+
+	mu     sync.Mutex
+	states map[stateKey]*hostState
+}
+
+// InitHostLimiter 根据配置创建限流器,任务携带的配置在每次调用时传入,不修改全局配置
+// 配置中的 host 同时匹配其子域名,如 zhipin.com 匹配 www.zhipin.com
+func InitHostLimiter(cfg *config.Config) HostLimiter {
+	limiter := &hostLimiter{
+		limits: make(map[string]config.HostLimit),
+		states: make(map[stateKey]*hostState),
+	}
+	limiter.defaultLimit, limiter.limits = indexLimits(cfg.RateLimits)
+	return limiter
+}
+
+// indexLimits 按host整理配置,返回默认配置和各host的配置
+func indexLimits(limits []config.HostLimit) (*config.HostLimit, map[string]config.HostLimit) {
+	var defaultLimit *config.HostLimit
+	byHost := make(map[string]config.HostLimit, len(limits))
+	for _, limit := range limits {
+		if limit.Host == "" {
+			defaultLimit = &limit
+			continue
+		}
+		byHost[strings.ToLower(limit.Host)] = limit
+	}
+	return defaultLimit, byHost
+}
+
+func (l *hostLimiter) Acquire(ctx context.Context, rawURL string, overrides []config.HostLimit) (func(), error) {
+	host, err := parseHost(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	state := l.state(host, overrides)
+
+	// 先占用并发名额再取令牌,令牌的等待时间才与实际导航时间一致
+	release := func() {}
+	if state.pages != nil {
+		select {
+		case state.pages <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		release = func() { <-state.pages }
+	}
+
+	if err := waitToken(ctx, host, state); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+func (l *hostLimiter) Wait(ctx context.Context, rawURL string, overrides []config.HostLimit) error {
+	host, err := parseHost(rawURL)
+	if err != nil {
+		return err
+	}
+	return waitToken(ctx, host, l.state(host, overrides))
+}
+
+// waitToken 等待一个速率令牌, ctx 结束时归还预占的令牌
+func waitToken(ctx context.Context, host string, state *hostState) error {
+	if state.bucket == nil {
+		return nil
+	}
+	wait := state.bucket.reserve()
+	if wait <= 0 {
+		return nil
+	}
+	log.Printf("host %s 限速, 等待 %v", host, wait.Round(time.Millisecond))
+	select {
+	case <-time.After(wait):
+		return nil
+	case <-ctx.Done():
+		state.bucket.cancel()
+		return ctx.Err()
+	}
+}
+
+func parseHost(rawURL string) (string, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("解析URL失败: %v", err)
+	}
+	return strings.ToLower(target.Hostname()), nil
+}
+
+// state 返回host的限流状态,同一个配置项下的所有host共享状态
+func (l *hostLimiter) state(host string, overrides []config.HostLimit) *hostState {
+	key, ok := l.lookup(host, overrides)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if state, exists := l.states[key]; exists {
+		return state
+	}
+	state := &hostState{}
+	if ok {
+		if key.limit.RequestsPerSecond > 0 {
+			state.bucket = newTokenBucket(key.limit.RequestsPerSecond, key.limit.Burst)
+		}
+		if key.limit.MaxConcurrentPages > 0 {
+			state.pages = make(chan struct{}, key.limit.MaxConcurrentPages)
+		}
+	}
+	l.states[key] = state
+	return state
+}
+
+// lookup 返回最具体的配置项,任务携带的配置优先于全局配置,使用默认配置时每个host有独立的状态
+func (l *hostLimiter) lookup(host string, overrides []config.HostLimit) (stateKey, bool) {
+	defaultLimit, limits := l.defaultLimit, l.limits
+	if len(overrides) > 0 {
+		overrideDefault, overrideLimits := indexLimits(overrides)
+		if overrideDefault != nil {
+			defaultLimit = overrideDefault
+		}
+		merged := make(map[string]config.HostLimit, len(limits)+len(overrideLimits))
+		for configured, limit := range limits {
+			merged[configured] = limit
+		}
+		for configured, limit := range overrideLimits {
+			merged[configured] = limit
+		}
+		limits = merged
+	}
+
+	best := ""
+	for configured := range limits {
+		if (host == configured || strings.HasSuffix(host, "."+configured)) && len(configured) > len(best) {
+			best = configured
+		}
+	}
+	if best != "" {
+		return stateKey{host: best, limit: limits[best]}, true
+	}
+	if defaultLimit != nil {
+		return stateKey{host: host, limit: *defaultLimit}, true
+	}
+	return stateKey{host: host}, false
+}
@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"crawleragent-v2/internal/config"
+	"testing"
+)
+
+func TestHostLimiterLookup(t *testing.T) {
+	limiter := InitHostLimiter(&config.Config{
+		RateLimits: []config.HostLimit{
+			{RequestsPerSecond: 5},
+			{Host: "example.com", RequestsPerSecond: 1},
+			{Host: "api.example.com", RequestsPerSecond: 2},
+		},
+	}).(*hostLimiter)
+
+	tests := []struct {
+		name      string
+		host      string
+		overrides []config.HostLimit
+		wantHost  string
+		wantRate  float64
+		wantFound bool
+	}{
+		{"精确匹配", "example.com", nil, "example.com", 1, true},
+		{"子域名匹配", "www.example.com", nil, "example.com", 1, true},
+		{"最具体的配置优先", "v1.api.example.com", nil, "api.example.com", 2, true},
+		{"后缀相同但不是子域名", "badexample.com", nil, "badexample.com", 5, true},
+		{"默认配置", "other.com", nil, "other.com", 5, true},
+		{"任务配置覆盖同一host", "www.example.com", []config.HostLimit{{Host: "example.com", RequestsPerSecond: 0.5}}, "example.com", 0.5, true},
+		{"任务配置中更具体的host", "www.example.com", []config.HostLimit{{Host: "www.example.com", RequestsPerSecond: 3}}, "www.example.com", 3, true},
+		{"任务配置中后出现的优先", "example.com", []config.HostLimit{{Host: "example.com", RequestsPerSecond: 3}, {Host: "example.com", RequestsPerSecond: 4}}, "example.com", 4, true},
+		{"任务默认配置", "other.com", []config.HostLimit{{RequestsPerSecond: 7}}, "other.com", 7, true},
+		{"任务默认配置不覆盖全局的host配置", "example.com", []config.HostLimit{{RequestsPerSecond: 7}}, "example.com", 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, found := limiter.lookup(tt.host, tt.overrides)
+			if found != tt.wantFound || key.host != tt.wantHost || key.limit.RequestsPerSecond != tt.wantRate {
+				t.Fatalf("lookup(%q) = %+v, %v, 期望 host=%s rate=%v found=%v",
+					tt.host, key, found, tt.wantHost, tt.wantRate, tt.wantFound)
+			}
+		})
+	}
+
+	// 任务配置只对本次调用生效
+	if key, _ := limiter.lookup("example.com", nil); key.limit.RequestsPerSecond != 1 {
+		t.Fatalf("任务配置不应修改全局配置, 速率为 %v", key.limit.RequestsPerSecond)
+	}
+}
+
+func TestHostLimiterNoLimit(t *testing.T) {
+	limiter := InitHostLimiter(&config.Config{})
+	release, err := limiter.Acquire(context.Background(), "https://example.com/a", nil)
+	if err != nil {
+		t.Fatalf("没有配置时不应限流: %v", err)
+	}
+	release()
+}
+
+func TestHostLimiterSeparateStates(t *testing.T) {
+	limiter := InitHostLimiter(&config.Config{
+		RateLimits: []config.HostLimit{{Host: "example.com", MaxConcurrentPages: 1}},
+	}).(*hostLimiter)
+	overrides := []config.HostLimit{{Host: "example.com", MaxConcurrentPages: 2}}
+
+	release, err := limiter.Acquire(context.Background(), "https://example.com/a", nil)
+	if err != nil {
+		t.Fatalf("占用页面名额失败: %v", err)
+	}
+	defer release()
+
+	// 不同的配置使用各自的状态,互不影响已占用的名额
+	global := limiter.state("example.com", nil)
+	task := limiter.state("example.com", overrides)
+	if global == task {
+		t.Fatalf("不同的配置不应共用状态")
+	}
+	if len(global.pages) != 1 || cap(task.pages) != 2 || len(task.pages) != 0 {
+		t.Fatalf("页面名额为 %d/%d 和 %d/%d, 期望 1/1 和 0/2",
+			len(global.pages), cap(global.pages), len(task.pages), cap(task.pages))
+	}
+	if limiter.state("www.example.com", nil) != global {
+		t.Fatalf("同一配置项下的host应共用状态")
+	}
+}
@@ -0,0 +1,15 @@
+package ratelimit
+
+import (
+	"context"
+	"crawleragent-v2/internal/config"
+)
+
+// HostLimiter 在导航前按host限制请求速率和同时打开的页面数
+type HostLimiter interface {
+	// Acquire 等待直到可以访问 url 所在的host,返回的 release 必须在页面关闭后调用
+	// overrides 是任务携带的限流配置,只对本次调用生效,优先于全局配置,后出现的覆盖前面的
+	Acquire(ctx context.Context, url string, overrides []config.HostLimit) (release func(), err error)
+	// Wait 只等待 url 所在host的速率令牌,不占用页面名额,用于已打开的页面中的后续导航和加载
+	Wait(ctx context.Context, url string, overrides []config.HostLimit) error
+}
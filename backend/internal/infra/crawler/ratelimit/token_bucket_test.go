@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// tolerance 是测试执行本身耗时造成的等待时间误差
+const tolerance = 50 * time.Millisecond
+
+func assertWait(t *testing.T, got, want time.Duration) {
+	t.Helper()
+	if got < want-tolerance || got > want+tolerance {
+		t.Fatalf("等待时间为 %v, 期望约 %v", got, want)
+	}
+}
+
+func TestTokenBucketReserve(t *testing.T) {
+	tests := []struct {
+		name  string
+		rate  float64
+		burst int
+		// want 是连续调用 reserve 的期望等待时间
+		want []time.Duration
+	}{
+		{"容量内不等待", 1, 3, []time.Duration{0, 0, 0}},
+		{"超出容量后排队", 1, 2, []time.Duration{0, 0, time.Second, 2 * time.Second}},
+		{"容量为 0 时按 1 处理", 2, 0, []time.Duration{0, 500 * time.Millisecond, time.Second}},
+		{"小数速率", 0.5, 1, []time.Duration{0, 2 * time.Second, 4 * time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket := newTokenBucket(tt.rate, tt.burst)
+			for i, want := range tt.want {
+				got := bucket.reserve()
+				if want == 0 && got != 0 {
+					t.Fatalf("第 %d 次等待时间为 %v, 期望不等待", i, got)
+				}
+				assertWait(t, got, want)
+			}
+		})
+	}
+}
+
+func TestTokenBucketRefill(t *testing.T) {
+	tests := []struct {
+		name    string
+		rate    float64
+		burst   int
+		tokens  float64
+		elapsed time.Duration
+		// wantTokens 是 reserve 取走一个令牌后剩余的令牌数
+		wantTokens float64
+		wantWait   time.Duration
+	}{
+		{"按速率补充", 2, 5, 0, time.Second, 1, 0},
+		{"补充不超过容量", 10, 3, 0, time.Hour, 2, 0},
+		{"补充不足时等待剩余部分", 1, 1, 0, 250 * time.Millisecond, -0.75, 750 * time.Millisecond},
+		{"排队的令牌逐渐补齐", 1, 1, -2, time.Second, -2, 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket := newTokenBucket(tt.rate, tt.burst)
+			bucket.tokens = tt.tokens
+			bucket.last = time.Now().Add(-tt.elapsed)
+
+			assertWait(t, bucket.reserve(), tt.wantWait)
+			if diff := bucket.tokens - tt.wantTokens; diff < -0.05 || diff > 0.05 {
+				t.Fatalf("剩余令牌数为 %v, 期望约 %v", bucket.tokens, tt.wantTokens)
+			}
+		})
+	}
+}
+
+func TestTokenBucketCancel(t *testing.T) {
+	bucket := newTokenBucket(1, 1)
+	bucket.reserve()
+	assertWait(t, bucket.reserve(), time.Second)
+	// 取消后归还令牌,下一个请求不需要为被取消的请求排队
+	bucket.cancel()
+	assertWait(t, bucket.reserve(), time.Second)
+
+	full := newTokenBucket(1, 2)
+	full.cancel()
+	if full.tokens != 2 {
+		t.Fatalf("归还后令牌数为 %v, 不应超过容量 2", full.tokens)
+	}
+}
@@ -0,0 +1,190 @@
+package robots
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultCacheTTL = 24 * time.Hour
+	// errorCacheTTL 是 robots.txt 获取失败时的缓存时间,失败结果不宜缓存太久
+	errorCacheTTL = 5 * time.Minute
+	// fetchTimeout 是获取 robots.txt 的超时时间,获取不受调用方 ctx 取消影响
+	fetchTimeout = 10 * time.Second
+	// maxRobotsSize 与 Google 的限制相同,超出部分忽略
+	maxRobotsSize = 500 * 1024
+)
+
+type hostEntry struct {
+	// mu 保护以下字段,不在获取 robots.txt 期间持有
+	mu    sync.Mutex
+	rules *rules
+	// err 是缓存的获取失败,不为空时 rules 无效
+	err       error
+	expireAt  time.Time
+	nextVisit time.Time
+}
+
+type httpRobots struct {
+	client    *http.Client
+	userAgent string
+	// ignoreHosts 中的host不检查 robots.txt,也不限速
+	ignoreHosts map[string]struct{}
+	cacheTTL    time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*hostEntry
+	// fetches 保证同一host同时只有一个 robots.txt 请求
+	fetches singleflight.Group
+}
+
+// InitRobots 创建按host缓存 robots.txt 的检查器
+// userAgent 用于请求 robots.txt 以及匹配规则组, robots.txt 由本机直接请求,不经过浏览器的代理
+func InitRobots(userAgent string, ignoreHosts []string, cacheTTL time.Duration) Robots {
+	if cacheTTL <= 0 {
+		cacheTTL = defaultCacheTTL
+	}
+	ignored := make(map[string]struct{}, len(ignoreHosts))
+	for _, host := range ignoreHosts {
+		ignored[strings.ToLower(host)] = struct{}{}
+	}
+	return &httpRobots{
+		client:      &http.Client{Timeout: fetchTimeout},
+		userAgent:   userAgent,
+		ignoreHosts: ignored,
+		cacheTTL:    cacheTTL,
+		hosts:       make(map[string]*hostEntry),
+	}
+}
+
+func (r *httpRobots) Wait(ctx context.Context, rawURL string) error {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("解析URL失败: %v", err)
+	}
+	// about:blank、data: 等非网络地址不受 robots.txt 约束
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return nil
+	}
+	if _, ok := r.ignoreHosts[strings.ToLower(target.Hostname())]; ok {
+		return nil
+	}
+
+	entry, err := r.load(ctx, target.Scheme+"://"+target.Host)
+	if err != nil {
+		return err
+	}
+	path := target.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if target.RawQuery != "" {
+		path += "?" + target.RawQuery
+	}
+	entry.mu.Lock()
+	if !entry.rules.allowed(path) {
+		entry.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrDisallowed, rawURL)
+	}
+
+	// 预约下一次访问时间,多个worker访问同一host时依次间隔 Crawl-delay
+	now := time.Now()
+	visitAt := now
+	if entry.nextVisit.After(now) {
+		visitAt = entry.nextVisit
+	}
+	entry.nextVisit = visitAt.Add(entry.rules.crawlDelay)
+	entry.mu.Unlock()
+
+	if wait := time.Until(visitAt); wait > 0 {
+		log.Printf("按 Crawl-delay 等待 %v 后访问 %s", wait.Round(time.Millisecond), rawURL)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	return nil
+}
+
+func (r *httpRobots) entry(origin string) *hostEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.hosts[origin]
+	if !ok {
+		entry = &hostEntry{}
+		r.hosts[origin] = entry
+	}
+	return entry
+}
+
+// load 返回规则未过期的host,过期时重新获取 robots.txt
+// 获取在后台进行且不受 ctx 取消影响, ctx 取消时只停止等待,取消不会被缓存
+func (r *httpRobots) load(ctx context.Context, origin string) (*hostEntry, error) {
+	entry := r.entry(origin)
+	entry.mu.Lock()
+	fresh, err := time.Now().Before(entry.expireAt), entry.err
+	entry.mu.Unlock()
+	if fresh {
+		return entry, err
+	}
+
+	result := r.fetches.DoChan(origin, func() (any, error) {
+		rules, ttl, err := r.fetch(context.WithoutCancel(ctx), origin)
+		entry.mu.Lock()
+		entry.rules, entry.err, entry.expireAt = rules, err, time.Now().Add(ttl)
+		entry.mu.Unlock()
+		return nil, err
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-result:
+		return entry, res.Err
+	}
+}
+
+// fetch 获取并解析 robots.txt,返回规则和缓存时间,按 RFC 9309 处理状态码:
+// 4xx 视为没有限制; 5xx 或网络错误返回包装了 ErrUnavailable 的错误,在 errorCacheTTL 后重试
+func (r *httpRobots) fetch(ctx context.Context, origin string) (*rules, time.Duration, error) {
+	robotsURL := origin + "/robots.txt"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return nil, errorCacheTTL, fmt.Errorf("%w: 创建 %s 请求失败: %v", ErrUnavailable, robotsURL, err)
+	}
+	if r.userAgent != "" {
+		req.Header.Set("User-Agent", r.userAgent)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		log.Printf("获取 %s 失败: %v", robotsURL, err)
+		return nil, errorCacheTTL, fmt.Errorf("%w: 获取 %s 失败: %v", ErrUnavailable, robotsURL, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxRobotsSize))
+		if err != nil {
+			log.Printf("读取 %s 失败: %v", robotsURL, err)
+			return nil, errorCacheTTL, fmt.Errorf("%w: 读取 %s 失败: %v", ErrUnavailable, robotsURL, err)
+		}
+		parsed := parse(body, r.userAgent)
+		log.Printf("已加载 %s, 规则 %d 条, Crawl-delay %v", robotsURL, len(parsed.rules), parsed.crawlDelay)
+		return parsed, r.cacheTTL, nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return allowAll, r.cacheTTL, nil
+	default:
+		log.Printf("获取 %s 返回状态码 %d, 暂时不访问该host", robotsURL, resp.StatusCode)
+		return nil, errorCacheTTL, fmt.Errorf("%w: %s 返回状态码 %d", ErrUnavailable, robotsURL, resp.StatusCode)
+	}
+}
@@ -0,0 +1,143 @@
+package robots
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type rule struct {
+	allow   bool
+	pattern string
+}
+
+// rules 是 robots.txt 中适用于本爬虫的规则组
+type rules struct {
+	rules      []rule
+	crawlDelay time.Duration
+}
+
+// allowAll 用于 robots.txt 不存在(4xx)的host
+var allowAll = &rules{}
+
+type group struct {
+	agents []string
+	rules
+}
+
+// parse 解析 robots.txt,返回与 userAgent 匹配的规则组,没有匹配的组时使用 * 组
+// userAgent 匹配不区分大小写,组名是 userAgent 的子串即视为匹配
+func parse(data []byte, userAgent string) *rules {
+	var (
+		groups  []*group
+		current *group
+		// inRules 为 true 时表示当前组已经出现过规则,新的 User-agent 行开始新组
+		inRules bool
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || inRules {
+				current = &group{}
+				groups = append(groups, current)
+				inRules = false
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			// 空的 Disallow 表示允许全部
+			if value == "" {
+				continue
+			}
+			current.rules.rules = append(current.rules.rules, rule{allow: key == "allow", pattern: value})
+		case "crawl-delay":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+
+	userAgent = strings.ToLower(userAgent)
+	var wildcard *group
+	for _, g := range groups {
+		for _, agent := range g.agents {
+			if agent == "*" {
+				if wildcard == nil {
+					wildcard = g
+				}
+				continue
+			}
+			if userAgent != "" && strings.Contains(userAgent, agent) {
+				return &g.rules
+			}
+		}
+	}
+	if wildcard != nil {
+		return &wildcard.rules
+	}
+	return allowAll
+}
+
+// allowed 按最长匹配原则判断路径是否允许访问,长度相同时 Allow 优先
+func (r *rules) allowed(path string) bool {
+	matchedLen := -1
+	allowed := true
+	for _, rule := range r.rules {
+		if !match(rule.pattern, path) {
+			continue
+		}
+		if len(rule.pattern) > matchedLen || (len(rule.pattern) == matchedLen && rule.allow) {
+			matchedLen = len(rule.pattern)
+			allowed = rule.allow
+		}
+	}
+	return allowed
+}
+
+// match 支持 * 通配任意字符和结尾的 $ 锚定
+func match(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	if len(parts) == 1 {
+		return !anchored || rest == ""
+	}
+	for _, part := range parts[1 : len(parts)-1] {
+		index := strings.Index(rest, part)
+		if index < 0 {
+			return false
+		}
+		rest = rest[index+len(part):]
+	}
+	last := parts[len(parts)-1]
+	if anchored {
+		return strings.HasSuffix(rest, last)
+	}
+	return strings.Contains(rest, last)
+}
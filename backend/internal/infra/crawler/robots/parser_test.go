@@ -0,0 +1,149 @@
+package robots
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"/", "/any/path", true},
+		{"/private", "/private", true},
+		{"/private", "/private/page.html", true},
+		{"/private", "/public", false},
+		{"/private/", "/private", false},
+		{"/*.php", "/index.php", true},
+		{"/*.php", "/dir/index.php?id=1", true},
+		{"/*.php", "/index.html", false},
+		{"/*.php$", "/index.php", true},
+		{"/*.php$", "/index.php?id=1", false},
+		{"/search$", "/search", true},
+		{"/search$", "/search/", false},
+		{"/a*b*c", "/a-x-b-y-c-z", true},
+		{"/a*b*c", "/a-x-c-y-b", false},
+		{"/a*b*c$", "/a-b-c", true},
+		{"/a*b*c$", "/a-b-c-d", false},
+		{"*", "/anything", true},
+		{"/*", "/", true},
+	}
+	for _, tt := range tests {
+		if got := match(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("match(%q, %q) = %v, 期望 %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAllowedLongestMatch(t *testing.T) {
+	data := []byte(`
+User-agent: *
+Disallow: /shop
+Allow: /shop/public
+Disallow: /shop/public/secret
+Allow: /*.css$
+Disallow: /tmp/
+Allow: /tmp/
+Disallow: /*?sort=
+`)
+	r := parse(data, "CrawlerAgent/2.0")
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/", true},
+		{"/shop", false},
+		{"/shop/cart", false},
+		{"/shop/public/item", true},
+		{"/shop/public/secret/1", false},
+		// /*.css$ 比 /shop 长,Allow 生效
+		{"/shop/style.css", true},
+		{"/shop/style.css?v=1", false},
+		// 长度相同时 Allow 优先
+		{"/tmp/file", true},
+		{"/list?sort=price", false},
+		{"/list?page=2", true},
+	}
+	for _, tt := range tests {
+		if got := r.allowed(tt.path); got != tt.want {
+			t.Errorf("allowed(%q) = %v, 期望 %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseGroups(t *testing.T) {
+	data := []byte(`
+# 注释行
+User-agent: *
+Disallow: /all
+Crawl-delay: 1
+
+User-agent: Googlebot
+User-agent: CrawlerAgent
+Disallow: /mine  # 行尾注释
+Crawl-delay: 2.5
+
+User-agent: other
+Disallow:
+`)
+	tests := []struct {
+		name      string
+		userAgent string
+		path      string
+		want      bool
+		delay     time.Duration
+	}{
+		{"匹配具体的组", "Mozilla/5.0 crawleragent/2.0", "/mine", false, 2500 * time.Millisecond},
+		{"具体的组不继承 * 组", "crawleragent", "/all", true, 2500 * time.Millisecond},
+		{"同组中的多个 User-agent", "Googlebot/2.1", "/mine", false, 2500 * time.Millisecond},
+		{"没有匹配时使用 * 组", "SomeBot", "/all", false, time.Second},
+		{"UA 为空时使用 * 组", "", "/mine", true, time.Second},
+		{"空的 Disallow 允许全部", "other", "/all", true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := parse(data, tt.userAgent)
+			if got := r.allowed(tt.path); got != tt.want {
+				t.Errorf("allowed(%q) = %v, 期望 %v", tt.path, got, tt.want)
+			}
+			if r.crawlDelay != tt.delay {
+				t.Errorf("crawlDelay = %v, 期望 %v", r.crawlDelay, tt.delay)
+			}
+		})
+	}
+}
+
+func TestParseCrawlDelay(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{"整数秒", "3", 3 * time.Second},
+		{"小数秒", "0.5", 500 * time.Millisecond},
+		{"零", "0", 0},
+		{"负数", "-1", 0},
+		{"无法解析", "fast", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := parse([]byte("User-agent: *\nCrawl-delay: "+tt.value+"\n"), "bot")
+			if r.crawlDelay != tt.want {
+				t.Errorf("crawlDelay = %v, 期望 %v", r.crawlDelay, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNoGroups(t *testing.T) {
+	// 没有 User-agent 行时规则无效,全部允许
+	r := parse([]byte("Disallow: /\nCrawl-delay: 10\n"), "bot")
+	if r != allowAll {
+		t.Fatalf("没有规则组时应返回 allowAll")
+	}
+	if !r.allowed("/anything") {
+		t.Errorf("没有规则组时应允许访问")
+	}
+}
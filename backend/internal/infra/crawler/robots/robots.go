@@ -0,0 +1,19 @@
+package robots
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrDisallowed 表示 robots.txt 不允许访问该URL
+var ErrDisallowed = errors.New("robots.txt 禁止访问")
+
+// ErrUnavailable 表示 robots.txt 暂时无法获取(网络错误或 5xx),任务应稍后重试,而不是当作禁止访问跳过
+var ErrUnavailable = errors.New("robots.txt 暂时无法获取")
+
+// Robots 是导航前的礼貌性检查
+type Robots interface {
+	// Wait 检查 robots.txt 是否允许访问 url,不允许时返回包装了 ErrDisallowed 的错误,
+	// 获取失败时返回包装了 ErrUnavailable 的错误;允许时按该host的 Crawl-delay 等待到可以访问为止
+	Wait(ctx context.Context, url string) error
+}
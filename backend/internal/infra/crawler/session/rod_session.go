@@ -0,0 +1,179 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// injectStorageJS 在每个文档加载前写入与当前 origin 对应的 localStorage
+const injectStorageJS = `(() => {
+	const items = (%s)[location.origin];
+	if (!items) return;
+	for (const [key, value] of Object.entries(items)) {
+		try { localStorage.setItem(key, value); } catch (e) {}
+	}
+})()`
+
+const readStorageJS = `() => {
+	const items = {};
+	for (let i = 0; i < localStorage.length; i++) {
+		const key = localStorage.key(i);
+		items[key] = localStorage.getItem(key);
+	}
+	return items;
+}`
+
+// Export 导出浏览器中的 cookie 以及 origins 的 localStorage
+// origins 为空时使用浏览器中已打开页面的 origin;origins 不为空时只导出这些站点的 cookie
+func Export(browser *rod.Browser, origins []string) (*Session, error) {
+	if len(origins) == 0 {
+		pages, err := browser.Pages()
+		if err != nil {
+			return nil, fmt.Errorf("获取页面失败: %v", err)
+		}
+		for _, page := range pages {
+			info, err := page.Info()
+			if err != nil {
+				continue
+			}
+			if origin := originOf(info.URL); origin != "" {
+				origins = append(origins, origin)
+			}
+		}
+	}
+
+	cookies, err := browser.GetCookies()
+	if err != nil {
+		return nil, fmt.Errorf("获取cookie失败: %v", err)
+	}
+
+	s := &Session{
+		SavedAt:      time.Now(),
+		LocalStorage: make(map[string]map[string]string),
+	}
+	for _, cookie := range cookies {
+		if len(origins) == 0 || cookieMatches(cookie, origins) {
+			s.Cookies = append(s.Cookies, cookie)
+		}
+	}
+
+	for _, origin := range origins {
+		if _, ok := s.LocalStorage[origin]; ok {
+			continue
+		}
+		items, err := readLocalStorage(browser, origin)
+		if err != nil {
+			log.Printf("读取 %s 的localStorage失败: %v", origin, err)
+			continue
+		}
+		s.LocalStorage[origin] = items
+	}
+	return s, nil
+}
+
+// Inject 在导航前将会话注入页面: cookie 立即写入浏览器, localStorage 在目标文档加载前写入
+// 返回的 remove 用于移除 localStorage 注入脚本
+func Inject(page *rod.Page, s *Session) (remove func() error, err error) {
+	if params := s.cookieParams(); len(params) > 0 {
+		if err := page.SetCookies(params); err != nil {
+			return nil, fmt.Errorf("注入cookie失败: %v", err)
+		}
+	}
+	if len(s.LocalStorage) == 0 {
+		return func() error { return nil }, nil
+	}
+	data, err := json.Marshal(s.LocalStorage)
+	if err != nil {
+		return nil, fmt.Errorf("序列化localStorage失败: %v", err)
+	}
+	remove, err = page.EvalOnNewDocument(fmt.Sprintf(injectStorageJS, data))
+	if err != nil {
+		return nil, fmt.Errorf("注入localStorage失败: %v", err)
+	}
+	return remove, nil
+}
+
+func readLocalStorage(browser *rod.Browser, origin string) (map[string]string, error) {
+	page, err := browser.Page(proto.TargetCreateTarget{URL: origin})
+	if err != nil {
+		return nil, err
+	}
+	defer page.Close()
+	page = page.Timeout(30 * time.Second)
+	if err := page.WaitLoad(); err != nil {
+		return nil, err
+	}
+	result, err := page.Eval(readStorageJS)
+	if err != nil {
+		return nil, err
+	}
+	items := make(map[string]string)
+	for key, value := range result.Value.Map() {
+		items[key] = value.Str()
+	}
+	return items, nil
+}
+
+func originOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// cookieMatches 判断 cookie 是否属于 origins 中的某个站点,包括父域名上的 cookie
+func cookieMatches(cookie *proto.NetworkCookie, origins []string) bool {
+	domain := strings.TrimPrefix(cookie.Domain, ".")
+	for _, origin := range origins {
+		u, err := url.Parse(origin)
+		if err != nil {
+			continue
+		}
+		host := u.Hostname()
+		if host == domain || strings.HasSuffix(host, "."+domain) || strings.HasSuffix(domain, "."+host) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply 将会话持久化写入浏览器的用户数据目录: 写入 cookie 并打开每个 origin 写入 localStorage
+func Apply(browser *rod.Browser, s *Session) error {
+	if params := s.cookieParams(); len(params) > 0 {
+		if err := browser.SetCookies(params); err != nil {
+			return fmt.Errorf("写入cookie失败: %v", err)
+		}
+	}
+	for origin, items := range s.LocalStorage {
+		if err := writeLocalStorage(browser, origin, items); err != nil {
+			return fmt.Errorf("写入 %s 的localStorage失败: %v", origin, err)
+		}
+	}
+	return nil
+}
+
+func writeLocalStorage(browser *rod.Browser, origin string, items map[string]string) error {
+	page, err := browser.Page(proto.TargetCreateTarget{URL: origin})
+	if err != nil {
+		return err
+	}
+	defer page.Close()
+	page = page.Timeout(30 * time.Second)
+	if err := page.WaitLoad(); err != nil {
+		return err
+	}
+	_, err = page.Eval(`items => {
+		for (const [key, value] of Object.entries(items)) {
+			localStorage.setItem(key, value);
+		}
+	}`, items)
+	return err
+}
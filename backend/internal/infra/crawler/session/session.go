@@ -0,0 +1,60 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Session 是从已登录浏览器导出的登录状态,可以注入到任意浏览器实例
+type Session struct {
+	SavedAt time.Time              `json:"saved_at"`
+	Cookies []*proto.NetworkCookie `json:"cookies"`
+	// LocalStorage 按 origin 保存,如 https://www.zhipin.com
+	LocalStorage map[string]map[string]string `json:"local_storage"`
+}
+
+func LoadSession(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取会话文件失败: %w", err)
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("解析会话文件失败: %w", err)
+	}
+	return &s, nil
+}
+
+func (s *Session) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化会话失败: %w", err)
+	}
+	// 会话中包含登录凭证,只允许当前用户读写
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("写入会话文件失败: %w", err)
+	}
+	return nil
+}
+
+// cookieParams 转换为可注入的 cookie,跳过已过期的 cookie
+func (s *Session) cookieParams() []*proto.NetworkCookieParam {
+	now := time.Now()
+	params := make([]*proto.NetworkCookieParam, 0, len(s.Cookies))
+	for _, cookie := range s.Cookies {
+		if !cookie.Session && cookie.Expires > 0 && cookie.Expires.Time().Before(now) {
+			continue
+		}
+		param := proto.CookiesToParams([]*proto.NetworkCookie{cookie})[0]
+		// 会话 cookie 的 expires 为 -1,原样注入会被当作已过期
+		if cookie.Session || param.Expires < 0 {
+			param.Expires = 0
+		}
+		params = append(params, param)
+	}
+	return params
+}
@@ -0,0 +1,29 @@
+package frontier
+
+import (
+	"context"
+	"crawleragent-v2/param"
+	"crawleragent-v2/types"
+)
+
+// Frontier 收集爬取过程中发现的链接,去重过滤后生成下一轮任务
+type Frontier interface {
+	// Seed 将种子URL标记为已访问,避免被再次发现
+	Seed(ctx context.Context, urls ...string) error
+	// Discover 处理在深度为 depth 的页面 pageURL 上发现的链接,相对链接以 pageURL 为基准,返回接受的数量
+	Discover(ctx context.Context, pageURL string, depth int, links []string) (int, error)
+	// Forget 将URL移出已发现集合,任务失败后调用,之后再次发现时会重新爬取
+	Forget(ctx context.Context, urls ...string) error
+	// Drain 取出当前所有待爬取的任务
+	Drain() []*param.ParallelCrawlerParam
+	// Process 可以注册为处理器,从JavaScript结果(URL数组或HTML字符串)或HTML响应中提取链接
+	Process(ctx context.Context, content types.UrlContent) error
+}
+
+// Deduper 记录已发现的URL
+type Deduper interface {
+	// MarkSeen 标记URL已发现,URL 第一次出现时返回 true
+	MarkSeen(ctx context.Context, url string) (bool, error)
+	// Unmark 取消URL的已发现标记
+	Unmark(ctx context.Context, url string) error
+}
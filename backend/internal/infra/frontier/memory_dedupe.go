@@ -0,0 +1,34 @@
+package frontier
+
+import (
+	"context"
+	"sync"
+)
+
+type memoryDeduper struct {
+	mu   sync.Mutex
+	seen map[string]struct{}
+}
+
+func InitMemoryDeduper() Deduper {
+	return &memoryDeduper{
+		seen: make(map[string]struct{}),
+	}
+}
+
+func (d *memoryDeduper) MarkSeen(ctx context.Context, url string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.seen[url]; ok {
+		return false, nil
+	}
+	d.seen[url] = struct{}{}
+	return true, nil
+}
+
+func (d *memoryDeduper) Unmark(ctx context.Context, url string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.seen, url)
+	return nil
+}
@@ -0,0 +1,66 @@
+package frontier
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Normalize 将 raw 解析为以 base 为基准的绝对URL,并规范化以便去重:
+// 只保留 http/https,小写 scheme 和 host,去掉默认端口、fragment,并按参数名排序查询参数
+func Normalize(base, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("URL为空")
+	}
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("解析URL失败: %v", err)
+	}
+	if base != "" {
+		baseURL, err := url.Parse(base)
+		if err != nil {
+			return "", fmt.Errorf("解析基准URL失败: %v", err)
+		}
+		ref = baseURL.ResolveReference(ref)
+	}
+
+	ref.Scheme = strings.ToLower(ref.Scheme)
+	if ref.Scheme != "http" && ref.Scheme != "https" {
+		return "", fmt.Errorf("不支持的协议: %s", ref.Scheme)
+	}
+	host := strings.ToLower(ref.Hostname())
+	if host == "" {
+		return "", fmt.Errorf("URL缺少host: %s", raw)
+	}
+	port := ref.Port()
+	if (ref.Scheme == "http" && port == "80") || (ref.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	ref.Host = host
+	if port != "" {
+		ref.Host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		// IPv6 地址需要保留方括号
+		ref.Host = "[" + host + "]"
+	}
+
+	ref.Fragment = ""
+	ref.RawFragment = ""
+	ref.User = nil
+	if ref.Path == "" {
+		ref.Path = "/"
+	}
+	if ref.RawQuery != "" {
+		query := ref.Query()
+		for _, values := range query {
+			sort.Strings(values)
+		}
+		// Encode 按参数名排序
+		ref.RawQuery = query.Encode()
+	}
+	ref.ForceQuery = false
+	return ref.String(), nil
+}
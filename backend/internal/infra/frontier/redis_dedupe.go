@@ -0,0 +1,61 @@
+package frontier
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisDedupeTTL 是去重集合在最后一次写入后的默认保留时间
+const DefaultRedisDedupeTTL = 24 * time.Hour
+
+// redisDeduper 使用Redis集合去重,多个进程或多次运行共享已发现的URL
+// 集合在最后一次写入 ttl 后过期,之后重新运行同名任务会重新爬取所有链接
+type redisDeduper struct {
+	client *redis.Client
+	key    string
+	ttl    time.Duration
+}
+
+// InitRedisDeduper 创建任务 name 的去重集合, ttl 不大于 0 时使用 DefaultRedisDedupeTTL
+func InitRedisDeduper(client *redis.Client, name string, ttl time.Duration) Deduper {
+	if ttl <= 0 {
+		ttl = DefaultRedisDedupeTTL
+	}
+	return &redisDeduper{
+		client: client,
+		key:    redisDedupeKey(name),
+		ttl:    ttl,
+	}
+}
+
+// ResetRedisDeduper 清空任务 name 的去重集合,用于在过期前重新爬取
+func ResetRedisDeduper(ctx context.Context, client *redis.Client, name string) error {
+	if err := client.Del(ctx, redisDedupeKey(name)).Err(); err != nil {
+		return fmt.Errorf("清空去重集合失败: %v", err)
+	}
+	return nil
+}
+
+func redisDedupeKey(name string) string {
+	return fmt.Sprintf("crawler:frontier:%s:seen", name)
+}
+
+func (d *redisDeduper) MarkSeen(ctx context.Context, url string) (bool, error) {
+	pipe := d.client.TxPipeline()
+	added := pipe.SAdd(ctx, d.key, url)
+	pipe.PExpire(ctx, d.key, d.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("写入去重集合失败: %v", err)
+	}
+	return added.Val() == 1, nil
+}
+
+func (d *redisDeduper) Unmark(ctx context.Context, url string) error {
+	if err := d.client.SRem(ctx, d.key, url).Err(); err != nil {
+		return fmt.Errorf("移出去重集合失败: %v", err)
+	}
+	return nil
+}
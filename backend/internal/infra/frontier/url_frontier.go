@@ -0,0 +1,211 @@
+package frontier
+
+import (
+	"bytes"
+	"context"
+	"crawleragent-v2/param"
+	"crawleragent-v2/types"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+type urlFrontier struct {
+	cfg      *param.FrontierParam
+	deduper  Deduper
+	includes []*regexp.Regexp
+	excludes []*regexp.Regexp
+
+	mu       sync.Mutex
+	pending  []*param.ParallelCrawlerParam
+	accepted int
+}
+
+func InitFrontier(cfg *param.FrontierParam, deduper Deduper) (Frontier, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("frontier配置无效: %v", err)
+	}
+	includes, err := param.CompilePatterns(cfg.Include)
+	if err != nil {
+		return nil, err
+	}
+	excludes, err := param.CompilePatterns(cfg.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	return &urlFrontier{
+		cfg:      cfg,
+		deduper:  deduper,
+		includes: includes,
+		excludes: excludes,
+	}, nil
+}
+
+func (f *urlFrontier) Seed(ctx context.Context, urls ...string) error {
+	for _, raw := range urls {
+		normalized, err := Normalize("", raw)
+		if err != nil {
+			return fmt.Errorf("种子URL %s 无效: %v", raw, err)
+		}
+		if _, err := f.deduper.MarkSeen(ctx, normalized); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Discover 在标记URL之前先占用 MaxURLs 名额,没有名额时不标记,
+// 这样因数量上限被丢弃的URL不会留在去重集合中,之后仍能被发现
+func (f *urlFrontier) Discover(ctx context.Context, pageURL string, depth int, links []string) (int, error) {
+	if depth+1 > f.cfg.MaxDepth {
+		return 0, nil
+	}
+	count := 0
+	for _, link := range links {
+		normalized, err := Normalize(pageURL, link)
+		if err != nil || !f.allowed(normalized) {
+			continue
+		}
+		if !f.reserve() {
+			break
+		}
+		isNew, err := f.deduper.MarkSeen(ctx, normalized)
+		if err != nil {
+			f.unreserve()
+			return count, err
+		}
+		if !isNew {
+			f.unreserve()
+			continue
+		}
+		f.schedule(normalized, depth+1)
+		count++
+	}
+	return count, nil
+}
+
+func (f *urlFrontier) Forget(ctx context.Context, urls ...string) error {
+	for _, raw := range urls {
+		normalized, err := Normalize("", raw)
+		if err != nil {
+			continue
+		}
+		if err := f.deduper.Unmark(ctx, normalized); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *urlFrontier) Drain() []*param.ParallelCrawlerParam {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tasks := f.pending
+	f.pending = nil
+	return tasks
+}
+
+func (f *urlFrontier) Process(ctx context.Context, content types.UrlContent) error {
+	links, err := extractLinks(content.GetContent())
+	if err != nil {
+		return fmt.Errorf("提取链接失败: %v", err)
+	}
+	depth := 0
+	if task, ok := param.TaskFromContext(ctx); ok {
+		depth = task.Depth
+	}
+	count, err := f.Discover(ctx, content.GetUrl(), depth, links)
+	if err != nil {
+		return err
+	}
+	log.Printf("从 %s 发现 %d 个链接, 新增 %d 个待爬取URL", content.GetUrl(), len(links), count)
+	return nil
+}
+
+func (f *urlFrontier) allowed(url string) bool {
+	for _, reg := range f.excludes {
+		if reg.MatchString(url) {
+			return false
+		}
+	}
+	if len(f.includes) == 0 {
+		return true
+	}
+	for _, reg := range f.includes {
+		if reg.MatchString(url) {
+			return true
+		}
+	}
+	return false
+}
+
+// reserve 占用一个 MaxURLs 名额,名额用完时返回 false
+func (f *urlFrontier) reserve() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.cfg.MaxURLs > 0 && f.accepted >= f.cfg.MaxURLs {
+		return false
+	}
+	f.accepted++
+	return true
+}
+
+// unreserve 归还 reserve 占用的名额
+func (f *urlFrontier) unreserve() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.accepted--
+}
+
+// schedule 以模板生成新任务,调用前必须已经通过 reserve 占用名额
+func (f *urlFrontier) schedule(url string, depth int) {
+	task := &param.ParallelCrawlerParam{}
+	if f.cfg.Template != nil {
+		// 浅拷贝即可,执行过程中不会修改 NetworkConfigs 和 Actions
+		*task = *f.cfg.Template
+	}
+	// 模板中的任务ID不能被多个任务共用
+	task.TaskID = ""
+	task.URL = url
+	task.Depth = depth
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pending = append(f.pending, task)
+}
+
+// extractLinks 支持三种内容: JavaScript 返回的URL数组、JavaScript 返回的HTML字符串、原始HTML
+func extractLinks(content []byte) ([]string, error) {
+	var result any
+	if err := json.Unmarshal(content, &result); err == nil {
+		switch value := result.(type) {
+		case []any:
+			links := make([]string, 0, len(value))
+			for _, item := range value {
+				if link, ok := item.(string); ok {
+					links = append(links, link)
+				}
+			}
+			return links, nil
+		case string:
+			content = []byte(value)
+		default:
+			return nil, fmt.Errorf("不支持的JSON内容类型: %T", result)
+		}
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	var links []string
+	doc.Find("a[href]").Each(func(_ int, selection *goquery.Selection) {
+		if href, ok := selection.Attr("href"); ok {
+			links = append(links, href)
+		}
+	})
+	return links, nil
+}
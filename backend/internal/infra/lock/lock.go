@@ -12,12 +12,16 @@ type lock struct {
 	client *redis.Client
 }
 
-func InitLock() Lock {
-	redisClient := redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379",
-		Password: "",
-		DB:       0,
-	})
+// refreshScript 仅在值匹配时续期,避免续期他人的锁
+var refreshScript = redis.NewScript(`
+    if redis.call("get", KEYS[1]) == ARGV[1] then
+        return redis.call("pexpire", KEYS[1], ARGV[2])
+    else
+        return 0
+    end
+    `)
+
+func InitLock(redisClient *redis.Client) Lock {
 	return &lock{
 		client: redisClient,
 	}
@@ -32,6 +36,18 @@ func (l *lock) Acquire(ctx context.Context, key string, timeout time.Duration) (
 	return value, success, nil
 }
 
+func (l *lock) AcquireWait(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	return acquireWait(ctx, l, key, timeout)
+}
+
+func (l *lock) Refresh(ctx context.Context, key, value string, timeout time.Duration) (bool, error) {
+	result, err := refreshScript.Run(ctx, l.client, []string{key}, value, timeout.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return result == 1, nil
+}
+
 func (l *lock) Release(ctx context.Context, key, value string) error {
 	luaScript := `
     if redis.call("get", KEYS[1]) == ARGV[1] then
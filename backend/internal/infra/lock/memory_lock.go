@@ -0,0 +1,77 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryEntry struct {
+	value    string
+	expireAt time.Time
+}
+
+// memoryLock 是进程内的 Lock 实现,用于单机运行,语义与Redis实现相同
+type memoryLock struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+}
+
+func InitMemoryLock() Lock {
+	return &memoryLock{
+		entries: make(map[string]*memoryEntry),
+	}
+}
+
+func (l *memoryLock) Acquire(ctx context.Context, key string, timeout time.Duration) (string, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.held(key, ""); ok {
+		return "", false, nil
+	}
+	value := uuid.New().String()
+	l.entries[key] = &memoryEntry{value: value, expireAt: time.Now().Add(timeout)}
+	return value, true, nil
+}
+
+func (l *memoryLock) AcquireWait(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	return acquireWait(ctx, l, key, timeout)
+}
+
+func (l *memoryLock) Refresh(ctx context.Context, key, value string, timeout time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry, ok := l.held(key, value)
+	if !ok {
+		return false, nil
+	}
+	entry.expireAt = time.Now().Add(timeout)
+	return true, nil
+}
+
+func (l *memoryLock) Release(ctx context.Context, key, value string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.held(key, value); ok {
+		delete(l.entries, key)
+	}
+	return nil
+}
+
+// held 返回未过期的锁,value 不为空时还要求持有者匹配,调用方需持有 l.mu
+func (l *memoryLock) held(key, value string) (*memoryEntry, bool) {
+	entry, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !time.Now().Before(entry.expireAt) {
+		delete(l.entries, key)
+		return nil, false
+	}
+	if value != "" && entry.value != value {
+		return nil, false
+	}
+	return entry, true
+}
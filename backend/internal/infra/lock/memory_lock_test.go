@@ -0,0 +1,80 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryLockAcquireRelease(t *testing.T) {
+	l := InitMemoryLock()
+	ctx := context.Background()
+
+	value, ok, err := l.Acquire(ctx, "key", time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("获取锁失败: %v, %v", ok, err)
+	}
+	if _, ok, _ := l.Acquire(ctx, "key", time.Minute); ok {
+		t.Fatalf("锁被持有时不应获取成功")
+	}
+
+	// 非持有者释放不生效
+	if err := l.Release(ctx, "key", "other"); err != nil {
+		t.Fatalf("释放锁失败: %v", err)
+	}
+	if _, ok, _ := l.Acquire(ctx, "key", time.Minute); ok {
+		t.Fatalf("非持有者不应释放锁")
+	}
+
+	if err := l.Release(ctx, "key", value); err != nil {
+		t.Fatalf("释放锁失败: %v", err)
+	}
+	if _, ok, _ := l.Acquire(ctx, "key", time.Minute); !ok {
+		t.Fatalf("锁释放后应获取成功")
+	}
+}
+
+func TestMemoryLockExpireRefresh(t *testing.T) {
+	l := InitMemoryLock()
+	ctx := context.Background()
+
+	value, _, _ := l.Acquire(ctx, "key", 50*time.Millisecond)
+	if ok, err := l.Refresh(ctx, "key", "other", time.Minute); ok || err != nil {
+		t.Fatalf("非持有者不应续期成功: %v, %v", ok, err)
+	}
+	if ok, err := l.Refresh(ctx, "key", value, 100*time.Millisecond); !ok || err != nil {
+		t.Fatalf("续期失败: %v, %v", ok, err)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if ok, _ := l.Refresh(ctx, "key", value, time.Minute); ok {
+		t.Fatalf("锁过期后不应续期成功")
+	}
+	if _, ok, _ := l.Acquire(ctx, "key", time.Minute); !ok {
+		t.Fatalf("锁过期后应获取成功")
+	}
+}
+
+func TestAcquireWait(t *testing.T) {
+	l := InitMemoryLock()
+	ctx := context.Background()
+	value, _, _ := l.Acquire(ctx, "key", time.Minute)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		l.Release(ctx, "key", value)
+	}()
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := l.AcquireWait(waitCtx, "key", time.Minute); err != nil {
+		t.Fatalf("锁释放后应获取成功: %v", err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err := l.AcquireWait(timeoutCtx, "key", time.Minute)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("等待超时应返回 DeadlineExceeded, 实际为 %v", err)
+	}
+}
@@ -7,5 +7,9 @@ import (
 
 type Lock interface {
 	Acquire(ctx context.Context, key string, timeout time.Duration) (string, bool, error)
+	// AcquireWait 阻塞直到获取锁,获取失败时以指数退避重试,ctx 结束时返回 ctx 的错误
+	AcquireWait(ctx context.Context, key string, timeout time.Duration) (string, error)
+	// Refresh 在仍持有锁时将过期时间重置为 timeout,锁已过期或被他人持有时返回 false
+	Refresh(ctx context.Context, key, value string, timeout time.Duration) (bool, error)
 	Release(ctx context.Context, key, value string) error
 }
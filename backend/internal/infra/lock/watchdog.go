@@ -0,0 +1,86 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	minAcquireBackoff = 50 * time.Millisecond
+	maxAcquireBackoff = 2 * time.Second
+	// minRenewInterval 续期间隔的下限,ttl 很短时避免 NewTicker 收到 0
+	minRenewInterval = time.Millisecond
+)
+
+// ErrLockLost 表示锁(租约)已过期或被他人持有
+var ErrLockLost = errors.New("锁已丢失")
+
+// acquireWait 以指数退避重复调用 Acquire,供各个 Lock 实现复用
+func acquireWait(ctx context.Context, l Lock, key string, timeout time.Duration) (string, error) {
+	backoff := minAcquireBackoff
+	for {
+		value, ok, err := l.Acquire(ctx, key, timeout)
+		if err != nil {
+			return "", fmt.Errorf("获取锁 %s 失败: %v", key, err)
+		}
+		if ok {
+			return value, nil
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("等待锁 %s 超时: %w", key, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxAcquireBackoff)
+	}
+}
+
+// RenewFunc 续期一次租约,返回 false 表示租约已丢失
+type RenewFunc func(ctx context.Context) (bool, error)
+
+// Watchdog 每 ttl/3 调用一次 renew,直到 ctx 结束或调用返回的 cancel, ttl 必须为正数
+// 返回的 ctx 在租约丢失或连续 ttl 时间未能续期成功时取消,context.Cause 为 ErrLockLost
+func Watchdog(ctx context.Context, ttl time.Duration, renew RenewFunc) (context.Context, context.CancelFunc, error) {
+	if ttl <= 0 {
+		return nil, nil, fmt.Errorf("租约时长必须为正数: %v", ttl)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	go func() {
+		ticker := time.NewTicker(max(ttl/3, minRenewInterval))
+		defer ticker.Stop()
+		lastRenewed := time.Now()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			ok, err := renew(ctx)
+			switch {
+			case err != nil:
+				// Redis暂时不可用时继续重试,直到租约必然已过期
+				log.Printf("续期租约失败: %v", err)
+				if time.Since(lastRenewed) >= ttl {
+					cancel(fmt.Errorf("%w: 超过 %v 未能续期", ErrLockLost, ttl))
+					return
+				}
+			case !ok:
+				cancel(ErrLockLost)
+				return
+			default:
+				lastRenewed = time.Now()
+			}
+		}
+	}()
+	return ctx, func() { cancel(nil) }, nil
+}
+
+// KeepAlive 为已获取的锁启动 Watchdog,适用于运行时间不确定的爬取任务
+func KeepAlive(ctx context.Context, l Lock, key, value string, ttl time.Duration) (context.Context, context.CancelFunc, error) {
+	return Watchdog(ctx, ttl, func(ctx context.Context) (bool, error) {
+		return l.Refresh(ctx, key, value, ttl)
+	})
+}
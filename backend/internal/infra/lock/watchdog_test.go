@@ -0,0 +1,107 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatchdogRenews(t *testing.T) {
+	var renewals atomic.Int32
+	ctx, cancel, err := Watchdog(context.Background(), 30*time.Millisecond, func(ctx context.Context) (bool, error) {
+		renewals.Add(1)
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("启动 watchdog 失败: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if ctx.Err() != nil {
+		t.Fatalf("续期成功时 ctx 不应取消: %v", context.Cause(ctx))
+	}
+	if renewals.Load() < 2 {
+		t.Fatalf("100ms 内续期 %d 次, 期望至少 2 次", renewals.Load())
+	}
+
+	cancel()
+	if cause := context.Cause(ctx); !errors.Is(cause, context.Canceled) {
+		t.Fatalf("调用 cancel 后 cause 应为 Canceled, 实际为 %v", cause)
+	}
+}
+
+func TestWatchdogLeaseLost(t *testing.T) {
+	ctx, cancel, err := Watchdog(context.Background(), 30*time.Millisecond, func(ctx context.Context) (bool, error) {
+		return false, nil
+	})
+	if err != nil {
+		t.Fatalf("启动 watchdog 失败: %v", err)
+	}
+	defer cancel()
+	waitDone(t, ctx)
+	if cause := context.Cause(ctx); !errors.Is(cause, ErrLockLost) {
+		t.Fatalf("租约丢失后 cause 应为 ErrLockLost, 实际为 %v", cause)
+	}
+}
+
+func TestWatchdogRenewErrors(t *testing.T) {
+	ctx, cancel, err := Watchdog(context.Background(), 30*time.Millisecond, func(ctx context.Context) (bool, error) {
+		return false, errors.New("连接失败")
+	})
+	if err != nil {
+		t.Fatalf("启动 watchdog 失败: %v", err)
+	}
+	defer cancel()
+	waitDone(t, ctx)
+	if cause := context.Cause(ctx); !errors.Is(cause, ErrLockLost) {
+		t.Fatalf("持续续期失败后 cause 应为 ErrLockLost, 实际为 %v", cause)
+	}
+}
+
+func TestWatchdogInvalidTTL(t *testing.T) {
+	renew := func(ctx context.Context) (bool, error) { return true, nil }
+	for _, ttl := range []time.Duration{0, -time.Second} {
+		if _, _, err := Watchdog(context.Background(), ttl, renew); err == nil {
+			t.Fatalf("ttl 为 %v 时应返回错误", ttl)
+		}
+	}
+	// ttl 小于 3ns 时续期间隔取下限,不应 panic
+	_, cancel, err := Watchdog(context.Background(), time.Nanosecond, renew)
+	if err != nil {
+		t.Fatalf("启动 watchdog 失败: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+}
+
+func TestKeepAlive(t *testing.T) {
+	l := InitMemoryLock()
+	value, _, _ := l.Acquire(context.Background(), "key", 50*time.Millisecond)
+	ctx, cancel, err := KeepAlive(context.Background(), l, "key", value, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("启动 KeepAlive 失败: %v", err)
+	}
+	defer cancel()
+
+	// 超过原始过期时间后锁仍被持有
+	time.Sleep(120 * time.Millisecond)
+	if _, ok, _ := l.Acquire(context.Background(), "key", time.Minute); ok {
+		t.Fatalf("KeepAlive 期间锁不应过期")
+	}
+
+	l.Release(context.Background(), "key", value)
+	waitDone(t, ctx)
+	if cause := context.Cause(ctx); !errors.Is(cause, ErrLockLost) {
+		t.Fatalf("锁被释放后 cause 应为 ErrLockLost, 实际为 %v", cause)
+	}
+}
+
+func waitDone(t *testing.T, ctx context.Context) {
+	t.Helper()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("ctx 未在 1s 内取消")
+	}
+}
@@ -0,0 +1,23 @@
+package rdb
+
+import (
+	"context"
+	"crawleragent-v2/internal/config"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// InitRedisClient 根据配置创建Redis客户端,并确认服务可用
+func InitRedisClient(ctx context.Context, cfg *config.Config) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("连接Redis %s 失败: %v", cfg.Redis.Addr, err)
+	}
+	return client, nil
+}
@@ -0,0 +1,181 @@
+package queue
+
+import (
+	"context"
+	"crawleragent-v2/param"
+	"crawleragent-v2/types"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryLease struct {
+	value    string
+	expireAt time.Time
+}
+
+// memoryQueue 是进程内的 TaskQueue 实现,语义与 redisQueue 相同,
+// 用于单机运行或在没有Redis的环境中替代 redisQueue
+type memoryQueue struct {
+	mu          sync.Mutex
+	maxAttempts int
+
+	pending    []string
+	processing map[string]*memoryLease
+	attempts   map[string]int
+	// tasks 保存序列化后的任务,与 redisQueue 一样每次领取都得到独立的副本
+	tasks   map[string][]byte
+	reports map[string]*types.CrawlReport
+	done    int64
+	dead    []string
+}
+
+func InitMemoryQueue(maxAttempts int) TaskQueue {
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+	return &memoryQueue{
+		maxAttempts: maxAttempts,
+		processing:  make(map[string]*memoryLease),
+		attempts:    make(map[string]int),
+		tasks:       make(map[string][]byte),
+		reports:     make(map[string]*types.CrawlReport),
+	}
+}
+
+func (q *memoryQueue) Enqueue(ctx context.Context, params ...*param.ParallelCrawlerParam) ([]string, error) {
+	payloads := make([][]byte, 0, len(params))
+	for _, p := range params {
+		payload, err := json.Marshal(p)
+		if err != nil {
+			return nil, fmt.Errorf("序列化任务失败: %v", err)
+		}
+		payloads = append(payloads, payload)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	ids := make([]string, 0, len(params))
+	for _, payload := range payloads {
+		id := uuid.New().String()
+		q.tasks[id] = payload
+		q.pending = append(q.pending, id)
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (q *memoryQueue) Claim(ctx context.Context, lease time.Duration) (*Task, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending) == 0 {
+		return nil, nil
+	}
+	id := q.pending[0]
+	q.pending = q.pending[1:]
+	leaseValue := uuid.New().String()
+	q.processing[id] = &memoryLease{value: leaseValue, expireAt: time.Now().Add(lease)}
+	q.attempts[id]++
+
+	task := &Task{ID: id, Attempts: q.attempts[id], lease: leaseValue}
+	if err := json.Unmarshal(q.tasks[id], &task.Param); err != nil {
+		q.moveToDead(id, &types.CrawlReport{Status: types.TaskFailed, Error: fmt.Sprintf("解析任务失败: %v", err)})
+		return nil, fmt.Errorf("任务 %s 解析失败: %v", id, err)
+	}
+	return task, nil
+}
+
+func (q *memoryQueue) Renew(ctx context.Context, task *Task, lease time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	current, ok := q.heldLease(task)
+	if !ok {
+		return ErrLeaseLost
+	}
+	current.expireAt = time.Now().Add(lease)
+	return nil
+}
+
+func (q *memoryQueue) Ack(ctx context.Context, task *Task, report *types.CrawlReport) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.heldLease(task); !ok {
+		return ErrLeaseLost
+	}
+	delete(q.processing, task.ID)
+	delete(q.attempts, task.ID)
+	delete(q.tasks, task.ID)
+	q.reports[task.ID] = report
+	q.done++
+	return nil
+}
+
+func (q *memoryQueue) Nack(ctx context.Context, task *Task, report *types.CrawlReport) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.heldLease(task); !ok {
+		return ErrLeaseLost
+	}
+	if q.attempts[task.ID] < q.maxAttempts {
+		delete(q.processing, task.ID)
+		q.pending = append(q.pending, task.ID)
+		return nil
+	}
+	q.moveToDead(task.ID, report)
+	return nil
+}
+
+func (q *memoryQueue) RequeueExpired(ctx context.Context) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := time.Now()
+	count := 0
+	for id, current := range q.processing {
+		if now.Before(current.expireAt) {
+			continue
+		}
+		if q.attempts[id] < q.maxAttempts {
+			delete(q.processing, id)
+			// 与 redisQueue 一致,过期任务放在队首优先处理
+			q.pending = append([]string{id}, q.pending...)
+		} else {
+			q.moveToDead(id, nil)
+		}
+		count++
+	}
+	return count, nil
+}
+
+func (q *memoryQueue) Stats(ctx context.Context) (Stats, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return Stats{
+		Pending:    int64(len(q.pending)),
+		Processing: int64(len(q.processing)),
+		Done:       q.done,
+		Dead:       int64(len(q.dead)),
+	}, nil
+}
+
+// heldLease 返回 task 仍持有的租约,调用方需持有 q.mu
+func (q *memoryQueue) heldLease(task *Task) (*memoryLease, bool) {
+	current, ok := q.processing[task.ID]
+	if !ok || current.value != task.lease || !time.Now().Before(current.expireAt) {
+		return nil, false
+	}
+	return current, true
+}
+
+// moveToDead 调用方需持有 q.mu
+func (q *memoryQueue) moveToDead(id string, report *types.CrawlReport) {
+	delete(q.processing, id)
+	delete(q.attempts, id)
+	delete(q.tasks, id)
+	if report != nil {
+		q.reports[id] = report
+	}
+	q.dead = append(q.dead, id)
+}
@@ -0,0 +1,47 @@
+package queue
+
+import (
+	"context"
+	"crawleragent-v2/param"
+	"crawleragent-v2/types"
+	"errors"
+	"time"
+)
+
+// ErrLeaseLost 表示任务租约已过期或被其他worker领取
+var ErrLeaseLost = errors.New("任务租约已丢失")
+
+// Task 是从队列中领取的爬取任务
+type Task struct {
+	ID    string
+	Param *param.ParallelCrawlerParam
+	// Attempts 是包括本次在内被领取的次数
+	Attempts int
+	// lease 是租约锁的值,续期和确认时用于校验持有者
+	lease string
+}
+
+type Stats struct {
+	Pending    int64 `json:"pending"`
+	Processing int64 `json:"processing"`
+	Done       int64 `json:"done"`
+	Dead       int64 `json:"dead"`
+}
+
+// TaskQueue 是多个爬虫进程共享的任务队列
+// 领取任务时会持有一个带过期时间的租约,worker 需要在爬取期间定期续期,
+// 租约过期的任务会被 RequeueExpired 重新放回队列
+type TaskQueue interface {
+	Enqueue(ctx context.Context, params ...*param.ParallelCrawlerParam) ([]string, error)
+	// Claim 领取一个任务,队列为空时返回 nil
+	Claim(ctx context.Context, lease time.Duration) (*Task, error)
+	// Renew 续期任务租约,租约已丢失时返回 ErrLeaseLost
+	Renew(ctx context.Context, task *Task, lease time.Duration) error
+	// Ack 确认任务完成并保存报告
+	Ack(ctx context.Context, task *Task, report *types.CrawlReport) error
+	// Nack 任务失败,未超过最大尝试次数时重新入队,否则放入死信队列
+	Nack(ctx context.Context, task *Task, report *types.CrawlReport) error
+	// RequeueExpired 将租约已过期(worker 已退出或卡死)的任务重新入队,返回处理的任务数
+	RequeueExpired(ctx context.Context) (int, error)
+	Stats(ctx context.Context) (Stats, error)
+}
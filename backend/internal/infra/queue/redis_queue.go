@@ -0,0 +1,229 @@
+package queue
+
+import (
+	"context"
+	"crawleragent-v2/internal/infra/lock"
+	"crawleragent-v2/param"
+	"crawleragent-v2/types"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// claimScript 原子地将任务从 pending 移到 processing 并设置租约,
+// 避免领取后、加锁前被 RequeueExpired 误判为过期
+var claimScript = redis.NewScript(`
+    local id = redis.call("rpoplpush", KEYS[1], KEYS[2])
+    if not id then
+        return false
+    end
+    redis.call("set", ARGV[1] .. id, ARGV[2], "PX", ARGV[3])
+    local attempts = redis.call("hincrby", KEYS[3], id, 1)
+    local payload = redis.call("hget", KEYS[4], id)
+    return {id, attempts, payload}
+    `)
+
+// finishScript 在仍持有租约时结束任务:
+// ARGV[3] 为 "ack" 时标记完成; 为 "nack" 时未超过最大尝试次数则放回队尾,否则放入死信队列
+var finishScript = redis.NewScript(`
+    local leaseKey = ARGV[1] .. ARGV[2]
+    if redis.call("get", leaseKey) ~= ARGV[4] then
+        return -1
+    end
+    redis.call("del", leaseKey)
+    redis.call("lrem", KEYS[2], 0, ARGV[2])
+    if ARGV[3] == "nack" and tonumber(redis.call("hget", KEYS[3], ARGV[2]) or "0") < tonumber(ARGV[5]) then
+        redis.call("lpush", KEYS[1], ARGV[2])
+        return 0
+    end
+    redis.call("hset", KEYS[5], ARGV[2], ARGV[6])
+    redis.call("hdel", KEYS[3], ARGV[2])
+    redis.call("hdel", KEYS[4], ARGV[2])
+    if ARGV[3] == "ack" then
+        redis.call("incr", KEYS[6])
+    else
+        redis.call("lpush", KEYS[7], ARGV[2])
+    end
+    return 1
+    `)
+
+// requeueScript 将 processing 中租约已过期的任务放回 pending 队首,超过最大尝试次数的放入死信队列
+var requeueScript = redis.NewScript(`
+    local ids = redis.call("lrange", KEYS[2], 0, -1)
+    local count = 0
+    for _, id in ipairs(ids) do
+        if redis.call("exists", ARGV[1] .. id) == 0 then
+            redis.call("lrem", KEYS[2], 0, id)
+            if tonumber(redis.call("hget", KEYS[3], id) or "0") < tonumber(ARGV[2]) then
+                redis.call("rpush", KEYS[1], id)
+            else
+                redis.call("hdel", KEYS[3], id)
+                redis.call("hdel", KEYS[4], id)
+                redis.call("lpush", KEYS[5], id)
+            end
+            count = count + 1
+        end
+    end
+    return count
+    `)
+
+type redisQueue struct {
+	client      *redis.Client
+	lock        lock.Lock
+	maxAttempts int
+
+	pendingKey    string
+	processingKey string
+	attemptsKey   string
+	tasksKey      string
+	reportsKey    string
+	doneKey       string
+	deadKey       string
+	leasePrefix   string
+}
+
+// InitRedisQueue 创建名为 name 的队列,同名队列在多个进程间共享
+// 任务被领取超过 maxAttempts 次仍失败时放入死信队列
+func InitRedisQueue(client *redis.Client, name string, maxAttempts int) TaskQueue {
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+	prefix := fmt.Sprintf("crawler:queue:%s:", name)
+	return &redisQueue{
+		client:        client,
+		lock:          lock.InitLock(client),
+		maxAttempts:   maxAttempts,
+		pendingKey:    prefix + "pending",
+		processingKey: prefix + "processing",
+		attemptsKey:   prefix + "attempts",
+		tasksKey:      prefix + "tasks",
+		reportsKey:    prefix + "reports",
+		doneKey:       prefix + "done",
+		deadKey:       prefix + "dead",
+		leasePrefix:   prefix + "lease:",
+	}
+}
+
+func (q *redisQueue) Enqueue(ctx context.Context, params ...*param.ParallelCrawlerParam) ([]string, error) {
+	ids := make([]string, 0, len(params))
+	pipe := q.client.TxPipeline()
+	for _, p := range params {
+		payload, err := json.Marshal(p)
+		if err != nil {
+			return nil, fmt.Errorf("序列化任务失败: %v", err)
+		}
+		id := uuid.New().String()
+		pipe.HSet(ctx, q.tasksKey, id, payload)
+		pipe.LPush(ctx, q.pendingKey, id)
+		ids = append(ids, id)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("任务入队失败: %v", err)
+	}
+	return ids, nil
+}
+
+func (q *redisQueue) Claim(ctx context.Context, lease time.Duration) (*Task, error) {
+	leaseValue := uuid.New().String()
+	result, err := claimScript.Run(ctx, q.client,
+		[]string{q.pendingKey, q.processingKey, q.attemptsKey, q.tasksKey},
+		q.leasePrefix, leaseValue, lease.Milliseconds(),
+	).Slice()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("领取任务失败: %v", err)
+	}
+	id, _ := result[0].(string)
+	attempts, _ := result[1].(int64)
+	// 任务内容缺失时 Lua 返回的数组会在 nil 处截断
+	var payload string
+	if len(result) > 2 {
+		payload, _ = result[2].(string)
+	}
+
+	task := &Task{ID: id, Attempts: int(attempts), lease: leaseValue}
+	if err := json.Unmarshal([]byte(payload), &task.Param); err != nil {
+		// 无法解析的任务直接放入死信队列,避免反复领取
+		report := &types.CrawlReport{Status: types.TaskFailed, Error: fmt.Sprintf("解析任务失败: %v", err)}
+		if nackErr := q.finish(ctx, task, "dead", report); nackErr != nil {
+			return nil, nackErr
+		}
+		return nil, fmt.Errorf("任务 %s 解析失败: %v", id, err)
+	}
+	return task, nil
+}
+
+func (q *redisQueue) Renew(ctx context.Context, task *Task, lease time.Duration) error {
+	ok, err := q.lock.Refresh(ctx, q.leasePrefix+task.ID, task.lease, lease)
+	if err != nil {
+		return fmt.Errorf("续期任务租约失败: %v", err)
+	}
+	if !ok {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func (q *redisQueue) Ack(ctx context.Context, task *Task, report *types.CrawlReport) error {
+	return q.finish(ctx, task, "ack", report)
+}
+
+func (q *redisQueue) Nack(ctx context.Context, task *Task, report *types.CrawlReport) error {
+	return q.finish(ctx, task, "nack", report)
+}
+
+func (q *redisQueue) finish(ctx context.Context, task *Task, mode string, report *types.CrawlReport) error {
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("序列化报告失败: %v", err)
+	}
+	maxAttempts := q.maxAttempts
+	if mode == "dead" {
+		mode, maxAttempts = "nack", 0
+	}
+	result, err := finishScript.Run(ctx, q.client,
+		[]string{q.pendingKey, q.processingKey, q.attemptsKey, q.tasksKey, q.reportsKey, q.doneKey, q.deadKey},
+		q.leasePrefix, task.ID, mode, task.lease, maxAttempts, reportJSON,
+	).Int()
+	if err != nil {
+		return fmt.Errorf("结束任务失败: %v", err)
+	}
+	if result < 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func (q *redisQueue) RequeueExpired(ctx context.Context) (int, error) {
+	count, err := requeueScript.Run(ctx, q.client,
+		[]string{q.pendingKey, q.processingKey, q.attemptsKey, q.tasksKey, q.deadKey},
+		q.leasePrefix, q.maxAttempts,
+	).Int()
+	if err != nil {
+		return 0, fmt.Errorf("重新入队过期任务失败: %v", err)
+	}
+	return count, nil
+}
+
+func (q *redisQueue) Stats(ctx context.Context) (Stats, error) {
+	pipe := q.client.Pipeline()
+	pending := pipe.LLen(ctx, q.pendingKey)
+	processing := pipe.LLen(ctx, q.processingKey)
+	done := pipe.Get(ctx, q.doneKey)
+	dead := pipe.LLen(ctx, q.deadKey)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return Stats{}, fmt.Errorf("查询队列状态失败: %v", err)
+	}
+	doneCount, _ := done.Int64()
+	return Stats{
+		Pending:    pending.Val(),
+		Processing: processing.Val(),
+		Done:       doneCount,
+		Dead:       dead.Val(),
+	}, nil
+}
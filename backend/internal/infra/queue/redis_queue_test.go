@@ -0,0 +1,195 @@
+package queue
+
+import (
+	"context"
+	"crawleragent-v2/param"
+	"crawleragent-v2/types"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const testLease = time.Minute
+
+// newTestQueue 在进程内的 miniredis 上创建队列,执行与生产环境相同的 Lua 脚本
+func newTestQueue(t *testing.T, maxAttempts int) (TaskQueue, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return InitRedisQueue(client, "test", maxAttempts), server
+}
+
+func enqueue(t *testing.T, q TaskQueue, urls ...string) []string {
+	t.Helper()
+	params := make([]*param.ParallelCrawlerParam, 0, len(urls))
+	for _, url := range urls {
+		params = append(params, &param.ParallelCrawlerParam{URL: url})
+	}
+	ids, err := q.Enqueue(context.Background(), params...)
+	if err != nil {
+		t.Fatalf("入队失败: %v", err)
+	}
+	return ids
+}
+
+func claim(t *testing.T, q TaskQueue, lease time.Duration) *Task {
+	t.Helper()
+	task, err := q.Claim(context.Background(), lease)
+	if err != nil {
+		t.Fatalf("领取任务失败: %v", err)
+	}
+	if task == nil {
+		t.Fatalf("队列中应该有任务")
+	}
+	return task
+}
+
+func assertStats(t *testing.T, q TaskQueue, want Stats) {
+	t.Helper()
+	got, err := q.Stats(context.Background())
+	if err != nil {
+		t.Fatalf("查询队列状态失败: %v", err)
+	}
+	if got != want {
+		t.Fatalf("队列状态为 %+v, 期望 %+v", got, want)
+	}
+}
+
+func TestClaimAck(t *testing.T) {
+	q, _ := newTestQueue(t, 3)
+	ctx := context.Background()
+	ids := enqueue(t, q, "https://a.example.com", "https://b.example.com")
+	assertStats(t, q, Stats{Pending: 2})
+
+	// 先入队的任务先被领取
+	task := claim(t, q, testLease)
+	if task.ID != ids[0] || task.Param.URL != "https://a.example.com" || task.Attempts != 1 {
+		t.Fatalf("领取到的任务不正确: %+v, %+v", task, task.Param)
+	}
+	assertStats(t, q, Stats{Pending: 1, Processing: 1})
+
+	if err := q.Ack(ctx, task, &types.CrawlReport{Status: types.TaskSucceeded}); err != nil {
+		t.Fatalf("确认任务失败: %v", err)
+	}
+	assertStats(t, q, Stats{Pending: 1, Done: 1})
+
+	claim(t, q, testLease)
+	empty, err := q.Claim(ctx, testLease)
+	if err != nil || empty != nil {
+		t.Fatalf("队列为空时应返回 nil, 实际为 %+v, %v", empty, err)
+	}
+}
+
+func TestNackRequeueThenDead(t *testing.T) {
+	q, _ := newTestQueue(t, 2)
+	ctx := context.Background()
+	enqueue(t, q, "https://a.example.com")
+	report := &types.CrawlReport{Status: types.TaskFailed}
+
+	task := claim(t, q, testLease)
+	if err := q.Nack(ctx, task, report); err != nil {
+		t.Fatalf("任务失败处理出错: %v", err)
+	}
+	assertStats(t, q, Stats{Pending: 1})
+
+	task = claim(t, q, testLease)
+	if task.Attempts != 2 {
+		t.Fatalf("第二次领取的尝试次数为 %d, 期望 2", task.Attempts)
+	}
+	if err := q.Nack(ctx, task, report); err != nil {
+		t.Fatalf("任务失败处理出错: %v", err)
+	}
+	assertStats(t, q, Stats{Dead: 1})
+}
+
+func TestRequeueExpired(t *testing.T) {
+	q, server := newTestQueue(t, 3)
+	ctx := context.Background()
+	ids := enqueue(t, q, "https://a.example.com", "https://b.example.com")
+
+	expired := claim(t, q, time.Second)
+	alive := claim(t, q, testLease)
+	server.FastForward(2 * time.Second)
+
+	count, err := q.RequeueExpired(ctx)
+	if err != nil {
+		t.Fatalf("重新入队过期任务失败: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("重新入队了 %d 个任务, 期望 1", count)
+	}
+	assertStats(t, q, Stats{Pending: 1, Processing: 1})
+
+	task := claim(t, q, testLease)
+	if task.ID != expired.ID || task.ID != ids[0] || task.Attempts != 2 {
+		t.Fatalf("重新领取的任务不正确: %+v", task)
+	}
+	if err := q.Ack(ctx, alive, &types.CrawlReport{}); err != nil {
+		t.Fatalf("未过期的任务确认失败: %v", err)
+	}
+}
+
+func TestRequeueExpiredMaxAttempts(t *testing.T) {
+	q, server := newTestQueue(t, 1)
+	enqueue(t, q, "https://a.example.com")
+
+	claim(t, q, time.Second)
+	server.FastForward(2 * time.Second)
+
+	count, err := q.RequeueExpired(context.Background())
+	if err != nil {
+		t.Fatalf("重新入队过期任务失败: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("处理了 %d 个任务, 期望 1", count)
+	}
+	assertStats(t, q, Stats{Dead: 1})
+}
+
+func TestLeaseLost(t *testing.T) {
+	q, server := newTestQueue(t, 3)
+	ctx := context.Background()
+	enqueue(t, q, "https://a.example.com")
+
+	task := claim(t, q, time.Second)
+	if err := q.Renew(ctx, task, time.Second); err != nil {
+		t.Fatalf("续期租约失败: %v", err)
+	}
+	server.FastForward(2 * time.Second)
+
+	if err := q.Renew(ctx, task, time.Second); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("租约过期后续期应返回 ErrLeaseLost, 实际为 %v", err)
+	}
+	if err := q.Ack(ctx, task, &types.CrawlReport{}); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("租约过期后确认应返回 ErrLeaseLost, 实际为 %v", err)
+	}
+
+	// 任务被其他worker重新领取后,原worker的租约不能结束该任务
+	if _, err := q.RequeueExpired(ctx); err != nil {
+		t.Fatalf("重新入队过期任务失败: %v", err)
+	}
+	other := claim(t, q, testLease)
+	if err := q.Nack(ctx, task, &types.CrawlReport{}); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("租约被他人持有时应返回 ErrLeaseLost, 实际为 %v", err)
+	}
+	if err := q.Ack(ctx, other, &types.CrawlReport{}); err != nil {
+		t.Fatalf("确认任务失败: %v", err)
+	}
+	assertStats(t, q, Stats{Done: 1})
+}
+
+func TestClaimInvalidPayload(t *testing.T) {
+	q, server := newTestQueue(t, 3)
+	ids := enqueue(t, q, "https://a.example.com")
+	server.HSet("crawler:queue:test:tasks", ids[0], "{")
+
+	task, err := q.Claim(context.Background(), testLease)
+	if err == nil || task != nil {
+		t.Fatalf("无法解析的任务应返回错误, 实际为 %+v, %v", task, err)
+	}
+	assertStats(t, q, Stats{Dead: 1})
+}
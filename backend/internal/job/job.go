@@ -0,0 +1,171 @@
+package job
+
+import (
+	"crawleragent-v2/param"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Job 是声明式的爬取任务文件,对应一次 CrawlerService.StartCrawling 调用
+type Job struct {
+	Name            string                        `json:"name"`
+	BrowserPoolSize int                           `json:"browser_pool_size"`
+	Tasks           []*param.ParallelCrawlerParam `json:"tasks"`
+	// Frontier 不为空时,处理器 frontier 发现的链接会作为新任务继续爬取
+	Frontier *param.FrontierParam `json:"frontier,omitempty"`
+	// IgnoreRobots 对任务文件中的所有任务(包括 frontier 生成的任务)生效
+	IgnoreRobots bool `json:"ignore_robots,omitempty"`
+	// Session 是所有任务默认使用的会话文件,任务中的 session 优先
+	Session string `json:"session,omitempty"`
+	// RateLimits 覆盖 config.yaml 中同一host的限流配置,会合并到每个任务中,队列中的任务同样生效
+	RateLimits []*param.HostLimit `json:"rate_limits,omitempty"`
+	// Block 是所有任务默认使用的请求拦截策略,任务中的 block 优先
+	Block *param.BlockPolicy `json:"block,omitempty"`
+	// Artifacts 是所有任务默认的调试产物配置,任务中的 artifacts 优先
+	Artifacts *param.ArtifactOptions `json:"artifacts,omitempty"`
+	// Humanize 对任务文件中的所有任务(包括 frontier 生成的任务)开启拟人化操作
+	Humanize bool `json:"humanize,omitempty"`
+}
+
+// LoadJob 根据文件扩展名解析 JSON 或 YAML 格式的任务文件
+// YAML 会先转换为 JSON,因此两种格式共用 param 中的 json 标签
+func LoadJob(path string) (*Job, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取任务文件失败: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		data, err = yamlToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("解析YAML任务文件失败: %w", err)
+		}
+	case ".json":
+	default:
+		return nil, fmt.Errorf("不支持的任务文件格式: %s", path)
+	}
+
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("解析任务文件失败: %w", err)
+	}
+	if len(job.Tasks) == 0 {
+		return nil, fmt.Errorf("任务文件 %s 中没有任务", path)
+	}
+	for i, task := range job.Tasks {
+		if task == nil {
+			return nil, fmt.Errorf("tasks[%d]: 任务不能为空", i)
+		}
+		if err := task.Validate(); err != nil {
+			return nil, fmt.Errorf("tasks[%d]: %w", i, err)
+		}
+	}
+	for i, limit := range job.RateLimits {
+		if limit == nil {
+			return nil, fmt.Errorf("rate_limits[%d]: 配置不能为空", i)
+		}
+		if err := limit.Validate(); err != nil {
+			return nil, fmt.Errorf("rate_limits[%d]: %w", i, err)
+		}
+	}
+	if job.Frontier != nil {
+		if err := job.Frontier.Validate(); err != nil {
+			return nil, fmt.Errorf("frontier: %w", err)
+		}
+	}
+	if job.Block != nil {
+		if err := job.Block.Validate(); err != nil {
+			return nil, fmt.Errorf("block: %w", err)
+		}
+	}
+
+	// 任务文件的默认配置同样作用于 frontier 生成的任务
+	targets := job.Tasks
+	if job.Frontier != nil {
+		if job.Frontier.Template == nil {
+			job.Frontier.Template = &param.ParallelCrawlerParam{}
+		}
+		targets = append(slices.Clone(job.Tasks), job.Frontier.Template)
+	}
+	for _, task := range targets {
+		job.applyDefaults(task)
+	}
+	return &job, nil
+}
+
+// applyDefaults 将任务文件的默认配置填入任务中未指定的字段
+func (j *Job) applyDefaults(task *param.ParallelCrawlerParam) {
+	if task.Block == nil {
+		task.Block = j.Block
+	}
+	if task.Session == "" {
+		task.Session = j.Session
+	}
+	if task.Artifacts == nil {
+		task.Artifacts = j.Artifacts
+	}
+	if len(j.RateLimits) > 0 {
+		// 任务中的配置排在后面,覆盖任务文件中同一host的配置
+		task.RateLimits = append(slices.Clone(j.RateLimits), task.RateLimits...)
+	}
+	task.IgnoreRobots = task.IgnoreRobots || j.IgnoreRobots
+	task.Humanize = task.Humanize || j.Humanize
+}
+
+// Resolve 将任务中按名称引用的处理器替换为注册表中的 ProcessFunc
+func (j *Job) Resolve(registry ProcessorRegistry) error {
+	for i, task := range j.Tasks {
+		if err := ResolveTask(task, registry); err != nil {
+			return fmt.Errorf("tasks[%d]: %w", i, err)
+		}
+	}
+	if j.Frontier != nil && j.Frontier.Template != nil {
+		if err := ResolveTask(j.Frontier.Template, registry); err != nil {
+			return fmt.Errorf("frontier.template: %w", err)
+		}
+	}
+	return nil
+}
+
+// ResolveTask 解析单个任务中引用的处理器,用于从队列中领取的任务
+func ResolveTask(task *param.ParallelCrawlerParam, registry ProcessorRegistry) error {
+	for _, networkConfig := range task.NetworkConfigs {
+		if networkConfig.Processor == "" {
+			continue
+		}
+		fn, ok := registry.Get(networkConfig.Processor)
+		if !ok {
+			return fmt.Errorf("引用了未注册的处理器: %s", networkConfig.Processor)
+		}
+		networkConfig.ProcessFunc = fn
+	}
+	var err error
+	param.WalkActions(task.Actions, func(action param.Action) {
+		jsAction, ok := action.(*param.JavaScriptAction)
+		if !ok || jsAction.Processor == "" || err != nil {
+			return
+		}
+		fn, ok := registry.Get(jsAction.Processor)
+		if !ok {
+			err = fmt.Errorf("引用了未注册的处理器: %s", jsAction.Processor)
+			return
+		}
+		jsAction.ProcessFunc = fn
+	})
+	return err
+}
+
+func yamlToJSON(data []byte) ([]byte, error) {
+	var content any
+	if err := yaml.Unmarshal(data, &content); err != nil {
+		return nil, err
+	}
+	return json.Marshal(content)
+}
@@ -0,0 +1,51 @@
+package job
+
+import (
+	"context"
+	"crawleragent-v2/types"
+	"fmt"
+	"sync"
+)
+
+// ProcessFunc 与 param 中的 ProcessFunc 签名一致,用于处理监听到的网络响应或JavaScript结果
+type ProcessFunc func(ctx context.Context, content types.UrlContent) error
+
+// ProcessorRegistry 按名称注册处理器,任务文件通过 processor 字段引用
+type ProcessorRegistry interface {
+	Register(name string, fn ProcessFunc) error
+	Get(name string) (ProcessFunc, bool)
+}
+
+type processorRegistry struct {
+	mu         sync.RWMutex
+	processors map[string]ProcessFunc
+}
+
+func InitProcessorRegistry() ProcessorRegistry {
+	return &processorRegistry{
+		processors: make(map[string]ProcessFunc),
+	}
+}
+
+func (r *processorRegistry) Register(name string, fn ProcessFunc) error {
+	if name == "" {
+		return fmt.Errorf("处理器名称不能为空")
+	}
+	if fn == nil {
+		return fmt.Errorf("处理器 %s 不能为空", name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.processors[name]; exists {
+		return fmt.Errorf("处理器 %s 已注册", name)
+	}
+	r.processors[name] = fn
+	return nil
+}
+
+func (r *processorRegistry) Get(name string) (ProcessFunc, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	fn, ok := r.processors[name]
+	return fn, ok
+}
@@ -29,14 +29,27 @@ func ProcessHTML(crawler ai.AICrawler) *compose.Lambda {
 		state["networkResponses"] = ""
 		state["cleanedHTML"] = ""
 
+		if params.Artifacts != nil {
+			taskID := param.NewTaskID()
+			if err := crawler.StartArtifacts(taskID, params.Artifacts); err != nil {
+				log.Printf("任务 %s 不保存产物: %v", taskID, err)
+			}
+			// 在路由器关闭后执行,请求日志完整
+			defer func() {
+				dir, files := crawler.FinishArtifacts()
+				if dir != "" {
+					log.Printf("产物保存在 %s: %v", dir, files)
+					state["artifactDir"] = dir
+				}
+			}()
+		}
+
 		if params.NetworkConfig != nil {
 			respChan := make(chan *types.NetworkResponse, params.NetworkConfig.RespChanSize)
 			defer close(respChan) // 确保通道被关闭
 
 			// 设置监听器
 			crawler.SetListener(ctx, params.NetworkConfig.URLPatterns, respChan)
-			crawler.RouterRun()
-			defer crawler.CloseRouter() // 确保路由器被关闭
 
 			var builder strings.Builder
 			var respCount int
@@ -52,17 +65,33 @@ func ProcessHTML(crawler ai.AICrawler) *compose.Lambda {
 			}()
 		}
 
+		// 拦截规则在监听器之后添加,监听的请求不会被拦截
+		if params.Block != nil {
+			if err := crawler.BlockRequests(params.Block); err != nil {
+				crawler.CloseRouter()
+				return nil, fmt.Errorf("block requests failed: %w", err)
+			}
+		}
+		if params.NetworkConfig != nil || params.Block != nil {
+			crawler.RouterRun()
+			defer crawler.CloseRouter() // 确保路由器被关闭
+		}
+
 		// 导航和执行操作
-		err := crawler.NavigateURL(url)
+		err := crawler.NavigateURL(ctx, url, params.IgnoreRobots)
 		if err != nil {
 			return nil, fmt.Errorf("crawl html failed: %w", err)
 		}
 
 		// 执行操作后立即关闭相关资源
+		actionOpts := ai.ActionOptions{IgnoreRobots: params.IgnoreRobots, Humanize: params.Humanize}
 		if params.NetworkConfig != nil {
-			err = crawler.ExecuteActions(params.Actions, params.NetworkConfig.URLPatterns, nil)
-		} else {
-			err = crawler.ExecuteActions(params.Actions, nil, nil)
+			actionOpts.WaitIncludes = params.NetworkConfig.URLPatterns
+		}
+		outcomes, err := crawler.ExecuteActions(ctx, params.Actions, actionOpts)
+		for _, outcome := range outcomes {
+			log.Printf("操作 %s(%s): %s, 尝试 %d 次, 耗时 %v %s",
+				outcome.Path, outcome.Type, outcome.Status, outcome.Attempts, outcome.Duration, outcome.Error)
 		}
 		if err != nil {
 			return nil, fmt.Errorf("execute actions failed: %w", err)
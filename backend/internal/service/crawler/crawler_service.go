@@ -5,8 +5,11 @@ import (
 	"crawleragent-v2/internal/data/model"
 	"crawleragent-v2/internal/infra/crawler/parallel"
 	"crawleragent-v2/internal/infra/embedding"
+	"crawleragent-v2/internal/infra/frontier"
 	"crawleragent-v2/internal/infra/persistence/es"
 	"crawleragent-v2/param"
+	"crawleragent-v2/types"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -34,12 +37,59 @@ func InitCrawlerService(parallelCrawler parallel.ParallelCrawler, embedder embed
 	}
 }
 
-func (c *crawlerService) StartCrawling(ctx context.Context, params []*param.ParallelCrawlerParam) error {
-	err := c.parallelCrawler.Crawl(ctx, params)
+func (c *crawlerService) StartCrawling(ctx context.Context, params []*param.ParallelCrawlerParam) ([]*types.CrawlReport, error) {
+	reports, err := c.parallelCrawler.Crawl(ctx, params)
 	if err != nil {
-		return fmt.Errorf("并行爬虫运行失败: %v", err)
+		return reports, fmt.Errorf("并行爬虫运行失败: %v", err)
 	}
-	return nil
+	return reports, nil
+}
+
+func (c *crawlerService) CrawlWithFrontier(ctx context.Context, seeds []*param.ParallelCrawlerParam, urlFrontier frontier.Frontier) ([]*types.CrawlReport, error) {
+	urls := make([]string, 0, len(seeds))
+	for _, seed := range seeds {
+		urls = append(urls, seed.URL)
+	}
+	if err := urlFrontier.Seed(ctx, urls...); err != nil {
+		return nil, fmt.Errorf("初始化frontier失败: %v", err)
+	}
+
+	var (
+		allReports []*types.CrawlReport
+		errs       []error
+	)
+	tasks := seeds
+	for round := 0; len(tasks) > 0; round++ {
+		log.Printf("第 %d 轮爬取, 共 %d 个URL", round, len(tasks))
+		reports, err := c.parallelCrawler.Crawl(ctx, tasks)
+		allReports = append(allReports, reports...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("第 %d 轮: %v", round, err))
+		}
+		if ctx.Err() != nil || errors.Is(err, parallel.ErrClosed) {
+			break
+		}
+		// 失败的URL移出去重集合,之后再次发现或重新运行时会重新爬取
+		if err := urlFrontier.Forget(ctx, failedURLs(reports)...); err != nil {
+			errs = append(errs, fmt.Errorf("第 %d 轮: %v", round, err))
+		}
+		tasks = urlFrontier.Drain()
+	}
+	if len(errs) > 0 {
+		return allReports, fmt.Errorf("并行爬虫运行失败: %v", errs)
+	}
+	return allReports, nil
+}
+
+// failedURLs 返回可以重试的失败任务的URL,被 robots.txt 禁止或遇到验证的任务不重试
+func failedURLs(reports []*types.CrawlReport) []string {
+	var urls []string
+	for _, report := range reports {
+		if report != nil && report.Status == types.TaskFailed {
+			urls = append(urls, report.URL)
+		}
+	}
+	return urls
 }
 
 func (c *crawlerService) EmbeddingAndIndexDocs(ctx context.Context, docs []model.Document) error {
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"crawleragent-v2/internal/data/entity"
+	"crawleragent-v2/internal/data/model"
+	"crawleragent-v2/internal/job"
+	"crawleragent-v2/types"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// RegisterProcessors 注册内置处理器,供任务文件通过名称引用
+func RegisterProcessors(registry job.ProcessorRegistry, crawlerService CrawlerService) error {
+	processors := map[string]job.ProcessFunc{
+		"boss_joblist_to_es": processBossJobList(crawlerService),
+		"log_content":        logContent,
+	}
+	for name, fn := range processors {
+		if err := registry.Register(name, fn); err != nil {
+			return fmt.Errorf("注册处理器失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// processBossJobList 解析Boss直聘职位列表接口的响应,嵌入后写入es
+func processBossJobList(crawlerService CrawlerService) job.ProcessFunc {
+	return func(ctx context.Context, content types.UrlContent) error {
+		var jsonData struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+			ZpData  struct {
+				HasMore    bool                    `json:"hasMore"`
+				JobResList []entity.RowBossJobData `json:"jobList"`
+			} `json:"zpData"`
+		}
+
+		if err := json.Unmarshal(content.GetContent(), &jsonData); err != nil {
+			return fmt.Errorf("JSON解析失败: %v", err)
+		}
+
+		if jsonData.Code != 0 {
+			return fmt.Errorf("API返回错误: %d - %s", jsonData.Code, jsonData.Message)
+		}
+
+		results := make([]model.Document, 0, len(jsonData.ZpData.JobResList))
+		for _, row := range jsonData.ZpData.JobResList {
+			results = append(results, row.ToDocument())
+		}
+
+		if err := crawlerService.EmbeddingAndIndexDocs(ctx, results); err != nil {
+			return fmt.Errorf("写入 %d 条职位失败: %w", len(results), err)
+		}
+		return nil
+	}
+}
+
+// logContent 仅打印内容摘要,用于调试任务文件
+func logContent(ctx context.Context, content types.UrlContent) error {
+	body := content.GetContent()
+	log.Printf("处理内容成功:%s, %d, %s", content.GetUrl(), len(body), string(body[:min(len(body), 100)]))
+	return nil
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"crawleragent-v2/internal/infra/lock"
+	"crawleragent-v2/internal/infra/queue"
+	"crawleragent-v2/internal/job"
+	"crawleragent-v2/param"
+	"crawleragent-v2/types"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+type QueueWorkerOptions struct {
+	// Workers 是同时领取任务的数量,一般等于浏览器池大小
+	Workers int
+	// Lease 是任务租约时长,worker 每 Lease/3 续期一次
+	Lease time.Duration
+	// PollInterval 是队列为空时的轮询间隔
+	PollInterval time.Duration
+	// ExitWhenEmpty 为 true 时,队列中没有待处理和处理中的任务后退出
+	ExitWhenEmpty bool
+	// ShutdownTimeout 是 ctx 取消后等待已领取任务完成的最长时间,超时后取消任务并退回队列
+	ShutdownTimeout time.Duration
+}
+
+func (c *crawlerService) ConsumeQueue(ctx context.Context, taskQueue queue.TaskQueue, registry job.ProcessorRegistry, opts QueueWorkerOptions) ([]*types.CrawlReport, error) {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = time.Minute
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 2 * time.Second
+	}
+	if opts.ShutdownTimeout <= 0 {
+		opts.ShutdownTimeout = 30 * time.Second
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// ctx 取消只停止领取新任务,已领取的任务使用 taskCtx 继续执行,等待超过 ShutdownTimeout 后取消
+	taskCtx, abortTasks := context.WithCancel(context.WithoutCancel(ctx))
+	defer abortTasks()
+	go func() {
+		select {
+		case <-taskCtx.Done():
+			return
+		case <-ctx.Done():
+		}
+		select {
+		case <-taskCtx.Done():
+		case <-time.After(opts.ShutdownTimeout):
+			log.Printf("等待执行中的任务超过 %v, 取消剩余任务", opts.ShutdownTimeout)
+			abortTasks()
+		}
+	}()
+
+	// 定期回收租约过期的任务,多个进程同时回收是安全的
+	go func() {
+		ticker := time.NewTicker(opts.Lease)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				count, err := taskQueue.RequeueExpired(ctx)
+				if err != nil {
+					log.Printf("回收过期任务失败: %v", err)
+				} else if count > 0 {
+					log.Printf("回收了 %d 个租约过期的任务", count)
+				}
+			}
+		}
+	}()
+
+	var (
+		mu      sync.Mutex
+		reports []*types.CrawlReport
+		errs    []error
+	)
+	wg := sync.WaitGroup{}
+	for workerID := range opts.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				task, err := taskQueue.Claim(ctx, opts.Lease)
+				if err != nil {
+					log.Printf("queue worker %d 领取任务失败: %v", workerID, err)
+				}
+				if task == nil {
+					if opts.ExitWhenEmpty && c.queueDrained(ctx, taskQueue) {
+						return
+					}
+					select {
+					case <-ctx.Done():
+						return
+					case <-time.After(opts.PollInterval):
+					}
+					continue
+				}
+
+				report, err := c.processTask(taskCtx, taskQueue, registry, task, opts.Lease)
+				mu.Lock()
+				reports = append(reports, report)
+				if err != nil {
+					errs = append(errs, err)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return reports, fmt.Errorf("%d errors occurred: %v", len(errs), errs)
+	}
+	return reports, nil
+}
+
+// processTask 爬取单个任务并持续续期租约,根据报告确认或退回任务
+// ctx 取消时终止爬取,任务仍会被退回队列
+func (c *crawlerService) processTask(ctx context.Context, taskQueue queue.TaskQueue, registry job.ProcessorRegistry, task *queue.Task, lease time.Duration) (*types.CrawlReport, error) {
+	log.Printf("领取任务 %s (第 %d 次): %s", task.ID, task.Attempts, task.Param.URL)
+	// 确认和退回任务不受 ctx 取消影响
+	queueCtx := context.WithoutCancel(ctx)
+
+	// 租约丢失后任务可能已被其他worker领取,watchdog 会取消 taskCtx 停止本次爬取
+	taskCtx, stopRenew, err := lock.Watchdog(ctx, lease, func(ctx context.Context) (bool, error) {
+		err := taskQueue.Renew(ctx, task, lease)
+		if errors.Is(err, queue.ErrLeaseLost) {
+			return false, nil
+		}
+		return err == nil, err
+	})
+	if err != nil {
+		report := &types.CrawlReport{URL: task.Param.URL, Status: types.TaskFailed, Error: err.Error()}
+		if nackErr := taskQueue.Nack(queueCtx, task, report); nackErr != nil {
+			log.Printf("退回任务 %s 失败: %v", task.ID, nackErr)
+		}
+		return report, fmt.Errorf("任务 %s 续期租约失败: %v", task.ID, err)
+	}
+	defer stopRenew()
+
+	var report *types.CrawlReport
+	if err := job.ResolveTask(task.Param, registry); err != nil {
+		report = &types.CrawlReport{
+			URL:    task.Param.URL,
+			Status: types.TaskFailed,
+			Error:  fmt.Sprintf("解析任务处理器失败: %v", err),
+		}
+	} else {
+		// 产物目录和报告使用队列中的任务ID
+		if task.Param.TaskID == "" {
+			task.Param.TaskID = task.ID
+		}
+		reports, _ := c.parallelCrawler.Crawl(taskCtx, []*param.ParallelCrawlerParam{task.Param})
+		report = reports[0]
+	}
+
+	if cause := context.Cause(taskCtx); errors.Is(cause, lock.ErrLockLost) {
+		return report, fmt.Errorf("任务 %s: %w", task.ID, cause)
+	}
+	// 遇到验证的任务退回队列,稍后重试
+	if report.Status == types.TaskFailed || report.Status == types.TaskChallenged {
+		if err := taskQueue.Nack(queueCtx, task, report); err != nil {
+			return report, fmt.Errorf("退回任务 %s 失败: %v", task.ID, err)
+		}
+		return report, fmt.Errorf("任务 %s 失败: %s", task.ID, report.Error)
+	}
+	if err := taskQueue.Ack(queueCtx, task, report); err != nil {
+		return report, fmt.Errorf("确认任务 %s 失败: %v", task.ID, err)
+	}
+	return report, nil
+}
+
+func (c *crawlerService) queueDrained(ctx context.Context, taskQueue queue.TaskQueue) bool {
+	stats, err := taskQueue.Stats(ctx)
+	if err != nil {
+		log.Printf("查询队列状态失败: %v", err)
+		return false
+	}
+	return stats.Pending == 0 && stats.Processing == 0
+}
@@ -3,10 +3,18 @@ package service
 import (
 	"context"
 	"crawleragent-v2/internal/data/model"
+	"crawleragent-v2/internal/infra/frontier"
+	"crawleragent-v2/internal/infra/queue"
+	"crawleragent-v2/internal/job"
 	"crawleragent-v2/param"
+	"crawleragent-v2/types"
 )
 
 type CrawlerService interface {
-	StartCrawling(ctx context.Context, params []*param.ParallelCrawlerParam) error
+	StartCrawling(ctx context.Context, params []*param.ParallelCrawlerParam) ([]*types.CrawlReport, error)
+	// CrawlWithFrontier 按深度逐轮爬取,每轮结束后从 frontier 中取出新发现的URL作为下一轮任务
+	CrawlWithFrontier(ctx context.Context, seeds []*param.ParallelCrawlerParam, urlFrontier frontier.Frontier) ([]*types.CrawlReport, error)
 	EmbeddingAndIndexDocs(ctx context.Context, docs []model.Document) error
+	// ConsumeQueue 从任务队列中持续领取并执行任务,返回本进程处理过的任务报告
+	ConsumeQueue(ctx context.Context, taskQueue queue.TaskQueue, registry job.ProcessorRegistry, opts QueueWorkerOptions) ([]*types.CrawlReport, error)
 }
@@ -0,0 +1,226 @@
+package param
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	actionRegistryMu sync.RWMutex
+	// actionFactories 按 type 名称创建操作实例
+	actionFactories = make(map[string]func() Action)
+	// actionTypeNames 根据具体类型反查 type 名称,用于序列化
+	actionTypeNames = make(map[reflect.Type]string)
+)
+
+// RegisterAction 注册一种操作类型,新的操作类型在各自文件的 init 中调用
+// factory 必须返回指针类型的新实例
+func RegisterAction(typeName string, factory func() Action) {
+	actionRegistryMu.Lock()
+	defer actionRegistryMu.Unlock()
+	if typeName == "" || factory == nil {
+		panic("param: RegisterAction 参数无效")
+	}
+	if _, exists := actionFactories[typeName]; exists {
+		panic(fmt.Sprintf("param: 操作类型 %s 重复注册", typeName))
+	}
+	actionFactories[typeName] = factory
+	actionTypeNames[reflect.TypeOf(factory())] = typeName
+}
+
+// ActionType 返回操作注册时的 type 名称
+func ActionType(action Action) (string, bool) {
+	actionRegistryMu.RLock()
+	defer actionRegistryMu.RUnlock()
+	typeName, ok := actionTypeNames[reflect.TypeOf(action)]
+	return typeName, ok
+}
+
+// ActionList 是可以从JSON/YAML任务文件中解析的操作列表
+// 每个操作通过 type 字段区分具体类型,例如:
+//
+//	{"type": "click_x", "selector": "//a[text()='>']", "delay": "2s"}
+type ActionList []Action
+
+func (l *ActionList) UnmarshalJSON(data []byte) error {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return fmt.Errorf("解析操作列表失败: %w", err)
+	}
+	actions := make(ActionList, 0, len(raws))
+	for i, raw := range raws {
+		action, err := UnmarshalAction(raw)
+		if err != nil {
+			return fmt.Errorf("actions[%d]: %w", i, err)
+		}
+		actions = append(actions, action)
+	}
+	*l = actions
+	return nil
+}
+
+func (l ActionList) MarshalJSON() ([]byte, error) {
+	raws := make([]json.RawMessage, 0, len(l))
+	for i, action := range l {
+		raw, err := MarshalAction(action)
+		if err != nil {
+			return nil, fmt.Errorf("actions[%d]: %w", i, err)
+		}
+		raws = append(raws, raw)
+	}
+	return json.Marshal(raws)
+}
+
+// Validate 依次校验每个操作,错误信息中包含出错操作的下标和类型
+func (l ActionList) Validate() error {
+	for i, action := range l {
+		if action == nil {
+			return fmt.Errorf("actions[%d]: 操作不能为空", i)
+		}
+		typeName, ok := ActionType(action)
+		if !ok {
+			typeName = fmt.Sprintf("%T", action)
+		}
+		if err := action.Validate(); err != nil {
+			return fmt.Errorf("actions[%d](%s): %w", i, typeName, err)
+		}
+		if base, ok := action.(interface{ GetBaseParams() *BaseParams }); ok {
+			if err := base.GetBaseParams().ValidateBase(); err != nil {
+				return fmt.Errorf("actions[%d](%s): %w", i, typeName, err)
+			}
+		}
+	}
+	return nil
+}
+
+// UnmarshalAction 解析带 type 字段的单个操作
+func UnmarshalAction(data []byte) (Action, error) {
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	typeName, _ := fields["type"].(string)
+	if typeName == "" {
+		return nil, fmt.Errorf("操作缺少 type 字段")
+	}
+	delete(fields, "type")
+
+	actionRegistryMu.RLock()
+	factory, ok := actionFactories[typeName]
+	actionRegistryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("未知操作类型: %s", typeName)
+	}
+	action := factory()
+
+	if err := normalizeDurations(fields, action); err != nil {
+		return nil, err
+	}
+	normalized, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(normalized, action); err != nil {
+		return nil, fmt.Errorf("解析 %s 操作失败: %w", typeName, err)
+	}
+	return action, nil
+}
+
+// MarshalAction 将操作序列化为带 type 字段的JSON,时长字段输出为 "2s" 形式
+func MarshalAction(action Action) ([]byte, error) {
+	if action == nil {
+		return nil, fmt.Errorf("操作不能为空")
+	}
+	typeName, ok := ActionType(action)
+	if !ok {
+		return nil, fmt.Errorf("未注册的操作类型: %T", action)
+	}
+	data, err := json.Marshal(action)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	formatDurations(fields, reflect.ValueOf(action))
+	fields["type"] = typeName
+	return json.Marshal(fields)
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// normalizeDurations 将 "2s"、"500ms" 这类字符串形式的时长转换为纳秒整数,
+// 以便 encoding/json 能直接解析到 time.Duration 字段
+func normalizeDurations(fields map[string]any, target any) error {
+	t := reflect.TypeOf(target)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if field.Anonymous {
+			if err := normalizeDurations(fields, reflect.New(field.Type).Interface()); err != nil {
+				return err
+			}
+			continue
+		}
+		if field.Type != durationType {
+			continue
+		}
+		key := jsonFieldName(field)
+		str, ok := fields[key].(string)
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(str)
+		if err != nil {
+			return fmt.Errorf("字段 %s 的时长格式无效: %w", key, err)
+		}
+		fields[key] = int64(d)
+	}
+	return nil
+}
+
+// formatDurations 是 normalizeDurations 的逆操作
+func formatDurations(fields map[string]any, v reflect.Value) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if field.Anonymous {
+			formatDurations(fields, v.Field(i))
+			continue
+		}
+		if field.Type != durationType {
+			continue
+		}
+		key := jsonFieldName(field)
+		if _, ok := fields[key]; ok {
+			fields[key] = time.Duration(v.Field(i).Int()).String()
+		}
+	}
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	tag := field.Tag.Get("json")
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name
+	}
+	return field.Name
+}
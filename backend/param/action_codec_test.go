@@ -0,0 +1,221 @@
+package param
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestActionRoundTrip(t *testing.T) {
+	base := BaseParams{
+		Delay:        2 * time.Second,
+		Timeout:      30 * time.Second,
+		Retries:      2,
+		RetryBackoff: 500 * time.Millisecond,
+		Optional:     true,
+	}
+	tests := []struct {
+		typeName string
+		action   Action
+	}{
+		{"click", &ClickAction{BaseParams: base, Selector: "#next"}},
+		{"click_x", &ClickXAction{BaseParams: base, Selector: "//a[text()='>']"}},
+		{"scroll", &ScrollAction{BaseParams: base, ScrollY: 800}},
+		{"javascript", &JavaScriptAction{BaseParams: base, JavaScript: "() => document.title", JavaScriptArgs: []any{"a", true}, Processor: "links"}},
+		{"input_text", &InputTextAction{BaseParams: base, Selector: "input[name=q]", Text: "golang", Clear: true}},
+		{"select_option", &SelectOptionAction{BaseParams: base, Selector: "select", Options: []string{"北京", "上海"}, MatchBy: "text"}},
+		{"hover", &HoverAction{BaseParams: base, Selector: ".menu"}},
+		{"press_key", &PressKeyAction{BaseParams: base, Key: "Enter", Modifiers: []string{"Control", "Shift"}, Selector: "input"}},
+		{"wait_for_selector", &WaitForSelectorAction{BaseParams: base, Selector: ".loading", State: "hidden"}},
+		{"wait_for_network", &WaitForNetworkAction{BaseParams: base, URLPattern: "*/api/list*"}},
+		{"navigate", &NavigateAction{BaseParams: base, URL: "https://example.com/page/2"}},
+		{"auto_scroll", &AutoScrollAction{BaseParams: base, URLPattern: "*/api/list*", QuietPeriod: 1500 * time.Millisecond, MaxRounds: 10, ItemSelector: ".item", MaxItems: 100, MaxTime: 2 * time.Minute}},
+		{"repeat", &RepeatAction{
+			BaseParams: base,
+			Actions:    ActionList{&ClickAction{Selector: ".more"}},
+			Times:      3,
+			UntilGone:  ".more",
+			UntilFalse: &JSONFlagCondition{URLPattern: "*/api/list*", Path: "data.hasMore"},
+		}},
+		{"if_exists", &IfExistsAction{
+			BaseParams: base,
+			Selector:   ".dialog",
+			Actions:    ActionList{&ClickAction{Selector: ".dialog .close"}},
+			Else:       ActionList{&ScrollAction{ScrollY: 100}},
+		}},
+		{"for_each_element", &ForEachElementAction{
+			BaseParams: base,
+			Selector:   ".card",
+			Actions:    ActionList{&HoverAction{Selector: ":scope"}},
+			Limit:      5,
+		}},
+	}
+
+	covered := make(map[string]bool, len(tests))
+	for _, tt := range tests {
+		covered[tt.typeName] = true
+		t.Run(tt.typeName, func(t *testing.T) {
+			data, err := MarshalAction(tt.action)
+			if err != nil {
+				t.Fatalf("序列化失败: %v", err)
+			}
+			var fields map[string]any
+			if err := json.Unmarshal(data, &fields); err != nil {
+				t.Fatalf("序列化结果不是JSON对象: %v", err)
+			}
+			if fields["type"] != tt.typeName {
+				t.Fatalf("type 为 %v, 期望 %s", fields["type"], tt.typeName)
+			}
+			if fields["delay"] != "2s" || fields["retry_backoff"] != "500ms" {
+				t.Fatalf("时长字段应输出为字符串: delay=%v retry_backoff=%v", fields["delay"], fields["retry_backoff"])
+			}
+
+			got, err := UnmarshalAction(data)
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.action) {
+				t.Fatalf("往返后为 %+v, 期望 %+v", got, tt.action)
+			}
+		})
+	}
+
+	actionRegistryMu.RLock()
+	defer actionRegistryMu.RUnlock()
+	for typeName := range actionFactories {
+		if !covered[typeName] {
+			t.Errorf("操作类型 %s 没有往返测试", typeName)
+		}
+	}
+}
+
+func TestUnmarshalActionErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"未知类型", `{"type": "teleport", "selector": "#a"}`, "未知操作类型: teleport"},
+		{"缺少类型", `{"selector": "#a"}`, "缺少 type 字段"},
+		{"类型为空", `{"type": "", "selector": "#a"}`, "缺少 type 字段"},
+		{"类型不是字符串", `{"type": 1}`, "缺少 type 字段"},
+		{"时长格式无效", `{"type": "click", "selector": "#a", "delay": "2 seconds"}`, "字段 delay 的时长格式无效"},
+		{"字段类型错误", `{"type": "scroll", "scroll_y": "down"}`, "解析 scroll 操作失败"},
+		{"不是对象", `["click"]`, "cannot unmarshal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := UnmarshalAction([]byte(tt.data))
+			if err == nil {
+				t.Fatalf("期望解析失败")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("错误为 %q, 期望包含 %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnmarshalActionDurations(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want BaseParams
+	}{
+		{"字符串", `{"type": "click", "selector": "#a", "delay": "2s", "timeout": "1m30s"}`, BaseParams{Delay: 2 * time.Second, Timeout: 90 * time.Second}},
+		{"毫秒", `{"type": "click", "selector": "#a", "retry_backoff": "250ms"}`, BaseParams{RetryBackoff: 250 * time.Millisecond}},
+		{"纳秒整数", `{"type": "click", "selector": "#a", "delay": 1000000000}`, BaseParams{Delay: time.Second}},
+		{"未指定", `{"type": "click", "selector": "#a"}`, BaseParams{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, err := UnmarshalAction([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			got := action.(*ClickAction).BaseParams
+			if got != tt.want {
+				t.Fatalf("通用参数为 %+v, 期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActionListNested(t *testing.T) {
+	data := `[
+		{"type": "repeat", "times": 2, "actions": [
+			{"type": "if_exists", "selector": ".list", "actions": [
+				{"type": "for_each_element", "selector": ".item", "limit": 3, "actions": [
+					{"type": "hover", "selector": ":scope", "delay": "100ms"}
+				]}
+			], "else": [
+				{"type": "navigate", "url": "https://example.com"}
+			]},
+			{"type": "click", "selector": ".next", "timeout": "5s"}
+		]}
+	]`
+	want := ActionList{
+		&RepeatAction{
+			Times: 2,
+			Actions: ActionList{
+				&IfExistsAction{
+					Selector: ".list",
+					Actions: ActionList{
+						&ForEachElementAction{
+							Selector: ".item",
+							Limit:    3,
+							Actions:  ActionList{&HoverAction{BaseParams: BaseParams{Delay: 100 * time.Millisecond}, Selector: ":scope"}},
+						},
+					},
+					Else: ActionList{&NavigateAction{URL: "https://example.com"}},
+				},
+				&ClickAction{BaseParams: BaseParams{Timeout: 5 * time.Second}, Selector: ".next"},
+			},
+		},
+	}
+
+	var got ActionList
+	if err := json.Unmarshal([]byte(data), &got); err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("解析结果与期望不一致")
+	}
+	if err := got.Validate(); err != nil {
+		t.Fatalf("校验失败: %v", err)
+	}
+
+	encoded, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("序列化失败: %v", err)
+	}
+	var again ActionList
+	if err := json.Unmarshal(encoded, &again); err != nil {
+		t.Fatalf("重新解析失败: %v", err)
+	}
+	if !reflect.DeepEqual(again, want) {
+		t.Fatalf("往返后与期望不一致: %s", encoded)
+	}
+}
+
+func TestActionListNestedErrorPath(t *testing.T) {
+	data := `[
+		{"type": "click", "selector": "#a"},
+		{"type": "repeat", "times": 1, "actions": [
+			{"type": "for_each_element", "selector": ".item", "actions": [
+				{"type": "unknown"}
+			]}
+		]}
+	]`
+	var got ActionList
+	err := json.Unmarshal([]byte(data), &got)
+	if err == nil {
+		t.Fatalf("期望解析失败")
+	}
+	want := "actions[1]: 解析 repeat 操作失败: actions[0]: 解析 for_each_element 操作失败: actions[0]: 未知操作类型: unknown"
+	if !strings.Contains(err.Error(), want) {
+		t.Fatalf("错误为 %q, 期望包含 %q", err, want)
+	}
+}
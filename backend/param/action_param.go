@@ -8,12 +8,53 @@ import (
 )
 
 // Action 接口
+// 新的操作类型需要在 init 中通过 RegisterAction 注册,才能从JSON/YAML中解析
 type Action interface {
 	Validate() error
 }
 
+func init() {
+	RegisterAction("click", func() Action { return &ClickAction{} })
+	RegisterAction("click_x", func() Action { return &ClickXAction{} })
+	RegisterAction("scroll", func() Action { return &ScrollAction{} })
+	RegisterAction("javascript", func() Action { return &JavaScriptAction{} })
+	RegisterAction("input_text", func() Action { return &InputTextAction{} })
+	RegisterAction("select_option", func() Action { return &SelectOptionAction{} })
+	RegisterAction("hover", func() Action { return &HoverAction{} })
+	RegisterAction("press_key", func() Action { return &PressKeyAction{} })
+	RegisterAction("wait_for_selector", func() Action { return &WaitForSelectorAction{} })
+	RegisterAction("wait_for_network", func() Action { return &WaitForNetworkAction{} })
+	RegisterAction("navigate", func() Action { return &NavigateAction{} })
+	RegisterAction("auto_scroll", func() Action { return &AutoScrollAction{} })
+}
+
 type BaseParams struct {
+	// Delay 操作成功后的等待时间
 	Delay time.Duration `json:"delay"`
+	// Timeout 单次尝试的超时时间,为 0 时使用执行器的默认值
+	Timeout time.Duration `json:"timeout"`
+	// Retries 失败后的重试次数
+	Retries int `json:"retries"`
+	// RetryBackoff 首次重试前的等待时间,之后每次翻倍
+	RetryBackoff time.Duration `json:"retry_backoff"`
+	// Optional 为 true 时操作最终失败也不会中断任务
+	Optional bool `json:"optional"`
+}
+
+// ValidateBase 校验通用参数,由 ActionList.Validate 统一调用
+func (b *BaseParams) ValidateBase() error {
+	if b.Delay < 0 || b.Timeout < 0 || b.RetryBackoff < 0 {
+		return fmt.Errorf("时长参数不能为负数")
+	}
+	if b.Retries < 0 {
+		return fmt.Errorf("重试次数不能为负数")
+	}
+	return nil
+}
+
+// GetBaseParams 供执行器统一读取各操作的通用参数
+func (b *BaseParams) GetBaseParams() *BaseParams {
+	return b
 }
 
 // Click 特定参数
@@ -58,8 +99,10 @@ type JavaScriptAction struct {
 	BaseParams
 	JavaScript     string `json:"javascript"`      // 要执行的 JavaScript 代码
 	JavaScriptArgs []any  `json:"javascript_args"` // JavaScript 参数
+	// Processor 是已注册处理器的名称,用于任务文件中替代 ProcessFunc
+	Processor string `json:"processor,omitempty"`
 	//ToDocFunc      func(ctx context.Context, content types.UrlContent) ([]model.Document, error)
-	ProcessFunc func(ctx context.Context, content types.UrlContent) error
+	ProcessFunc func(ctx context.Context, content types.UrlContent) error `json:"-"`
 }
 
 func (j *JavaScriptAction) Validate() error {
@@ -68,3 +111,163 @@ func (j *JavaScriptAction) Validate() error {
 	}
 	return nil
 }
+
+// InputText 特定参数
+type InputTextAction struct {
+	BaseParams
+	Selector string `json:"selector"`
+	Text     string `json:"text"`
+	// Clear 为 true 时先选中输入框中的已有内容再输入,即替换原内容
+	Clear bool `json:"clear"`
+}
+
+func (i *InputTextAction) Validate() error {
+	if i.Selector == "" {
+		return fmt.Errorf("输入操作必须指定选择器")
+	}
+	return nil
+}
+
+// SelectOption 特定参数
+type SelectOptionAction struct {
+	BaseParams
+	Selector string   `json:"selector"`
+	Options  []string `json:"options"`
+	// MatchBy 指定 Options 的匹配方式: text(默认,按选项文本)、css、regex
+	MatchBy string `json:"match_by"`
+}
+
+func (s *SelectOptionAction) Validate() error {
+	if s.Selector == "" {
+		return fmt.Errorf("选择操作必须指定选择器")
+	}
+	if len(s.Options) == 0 {
+		return fmt.Errorf("选择操作必须指定选项")
+	}
+	switch s.MatchBy {
+	case "", "text", "css", "regex":
+	default:
+		return fmt.Errorf("选择操作的匹配方式无效: %s", s.MatchBy)
+	}
+	return nil
+}
+
+// Hover 特定参数
+type HoverAction struct {
+	BaseParams
+	Selector string `json:"selector"`
+}
+
+func (h *HoverAction) Validate() error {
+	if h.Selector == "" {
+		return fmt.Errorf("悬停操作必须指定选择器")
+	}
+	return nil
+}
+
+// PressKey 特定参数
+type PressKeyAction struct {
+	BaseParams
+	// Key 为按键名称,如 Enter、Tab、Escape、ArrowDown,或单个可打印字符
+	Key string `json:"key"`
+	// Modifiers 为同时按住的修饰键: Control、Shift、Alt、Meta
+	Modifiers []string `json:"modifiers"`
+	// Selector 不为空时先聚焦该元素
+	Selector string `json:"selector"`
+}
+
+func (p *PressKeyAction) Validate() error {
+	if p.Key == "" {
+		return fmt.Errorf("按键操作必须指定按键")
+	}
+	if _, ok := LookupKey(p.Key); !ok {
+		return fmt.Errorf("未知按键: %s", p.Key)
+	}
+	for _, name := range p.Modifiers {
+		if _, ok := LookupModifier(name); !ok {
+			return fmt.Errorf("未知修饰键: %s, 只支持 Control、Shift、Alt、Meta", name)
+		}
+	}
+	return nil
+}
+
+// WaitForSelector 特定参数
+type WaitForSelectorAction struct {
+	BaseParams
+	Selector string `json:"selector"`
+	// State 为等待的状态: visible(默认)或 hidden,元素不存在也视为 hidden
+	State string `json:"state"`
+}
+
+func (w *WaitForSelectorAction) Validate() error {
+	if w.Selector == "" {
+		return fmt.Errorf("等待元素操作必须指定选择器")
+	}
+	switch w.State {
+	case "", "visible", "hidden":
+	default:
+		return fmt.Errorf("等待元素操作的状态无效: %s", w.State)
+	}
+	return nil
+}
+
+// WaitForNetwork 特定参数
+// 从上一个操作开始执行起,等待URL匹配 URLPattern 的响应到达
+type WaitForNetworkAction struct {
+	BaseParams
+	URLPattern string `json:"url_pattern"`
+}
+
+func (w *WaitForNetworkAction) Validate() error {
+	if w.URLPattern == "" {
+		return fmt.Errorf("等待网络操作必须指定URL模式")
+	}
+	return nil
+}
+
+// Navigate 特定参数
+type NavigateAction struct {
+	BaseParams
+	URL string `json:"url"`
+}
+
+func (n *NavigateAction) Validate() error {
+	if n.URL == "" {
+		return fmt.Errorf("导航操作必须指定URL")
+	}
+	return nil
+}
+
+// AutoScroll 特定参数
+// 反复滚动到页面底部加载更多内容,满足以下任一条件时停止:
+// 页面高度和匹配 URLPattern 的响应数量在 QuietPeriod 内都没有增长、达到 MaxRounds、
+// ItemSelector 匹配的元素数量达到 MaxItems、总耗时达到 MaxTime
+type AutoScrollAction struct {
+	BaseParams
+	// URLPattern 是判断是否加载了新内容的响应模式,需要与 network_configs 中的 url_pattern 一致,
+	// 为空时使用任务中所有 network_configs 的模式
+	URLPattern string `json:"url_pattern"`
+	// QuietPeriod 滚动后等待新内容的时长,为 0 时使用执行器的默认值
+	QuietPeriod time.Duration `json:"quiet_period"`
+	// MaxRounds 最大滚动轮数,为 0 时使用执行器的默认值
+	MaxRounds int `json:"max_rounds"`
+	// ItemSelector 和 MaxItems 同时指定时,页面中的条目数量达到 MaxItems 后停止
+	ItemSelector string `json:"item_selector"`
+	MaxItems     int    `json:"max_items"`
+	// MaxTime 滚动的总时长上限,为 0 时使用执行器的默认值,达到上限视为成功
+	// 指定的 Timeout 短于 MaxTime 时,滚动在超时前结束,同样视为达到上限
+	MaxTime time.Duration `json:"max_time"`
+}
+
+func (a *AutoScrollAction) Validate() error {
+	if a.QuietPeriod < 0 || a.MaxTime < 0 {
+		return fmt.Errorf("自动滚动操作的时长参数不能为负数")
+	}
+	if a.MaxRounds < 0 || a.MaxItems < 0 {
+		return fmt.Errorf("自动滚动操作的次数参数不能为负数")
+	}
+	if a.MaxItems > 0 && a.ItemSelector == "" {
+		return fmt.Errorf("自动滚动操作指定 max_items 时必须指定 item_selector")
+	}
+	return nil
+}
@@ -27,5 +27,13 @@ type AICrawlerParam struct {
 	Formats       Formats          `json:"formats"`
 	HTMLConfig    *AIHTMLConfig    `json:"html_config"`
 	NetworkConfig *AINetworkConfig `json:"network_config"`
-	Actions       []Action         `json:"actions"`
+	Actions       ActionList       `json:"actions"`
+	// Block 是请求拦截策略,为空时不拦截
+	Block *BlockPolicy `json:"block"`
+	// Artifacts 为空时不保存截图等调试产物
+	Artifacts *ArtifactOptions `json:"artifacts"`
+	// IgnoreRobots 为 true 时不检查 robots.txt,用于已获得授权的网站
+	IgnoreRobots bool `json:"ignore_robots"`
+	// Humanize 为 true 时操作模拟真实用户的鼠标移动、滚动和输入节奏,与 ParallelCrawlerParam.Humanize 相同
+	Humanize bool `json:"humanize"`
 }
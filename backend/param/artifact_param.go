@@ -0,0 +1,39 @@
+package param
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NewTaskID 为没有指定ID的任务生成ID,以时间开头便于按时间查找产物目录
+func NewTaskID() string {
+	return time.Now().Format("20060102-150405") + "-" + uuid.NewString()[:8]
+}
+
+// ValidateTaskID 检查任务ID能否直接作为产物目录名,不能包含路径分隔符,也不能是 . 或 ..
+func ValidateTaskID(id string) error {
+	if id == "." || id == ".." || strings.ContainsAny(id, `/\`) {
+		return fmt.Errorf("任务ID %q 不能包含路径分隔符或为 . 和 ..", id)
+	}
+	return nil
+}
+
+// ArtifactOptions 决定任务需要保存的调试产物,保存在 config.yaml 中 artifacts.dir 下以任务ID命名的目录
+type ArtifactOptions struct {
+	// Screenshot 在导航完成后和任务结束时截取整页截图
+	Screenshot bool `json:"screenshot,omitempty"`
+	// ActionScreenshots 在每个操作(控制流操作除外)执行后截图,失败的操作同样截图
+	ActionScreenshots bool `json:"action_screenshots,omitempty"`
+	// PDF 在任务结束时保存页面PDF,只在 headless 模式下可用
+	PDF bool `json:"pdf,omitempty"`
+	// HAR 以 HAR 格式记录监听器拦截到的请求和响应
+	HAR bool `json:"har,omitempty"`
+}
+
+// Enabled 判断是否开启了任意一种产物
+func (a *ArtifactOptions) Enabled() bool {
+	return a.Screenshot || a.ActionScreenshots || a.PDF || a.HAR
+}
@@ -0,0 +1,60 @@
+package param
+
+import (
+	"fmt"
+	"strings"
+)
+
+// blockResourceTypes 是可以拦截的资源类型,值为 CDP 中的名称
+var blockResourceTypes = map[string]string{
+	"image":      "Image",
+	"font":       "Font",
+	"media":      "Media",
+	"stylesheet": "Stylesheet",
+	"script":     "Script",
+	"texttrack":  "TextTrack",
+	"xhr":        "XHR",
+	"fetch":      "Fetch",
+	"websocket":  "WebSocket",
+	"manifest":   "Manifest",
+	"ping":       "Ping",
+	"other":      "Other",
+}
+
+// ResourceTypeName 返回资源类型在 CDP 中的名称,不区分大小写
+func ResourceTypeName(name string) (string, bool) {
+	cdpName, ok := blockResourceTypes[strings.ToLower(name)]
+	return cdpName, ok
+}
+
+// BlockPolicy 是请求拦截策略,命中的请求直接以 BlockedByClient 失败,用于只需要接口数据时节省带宽
+// network_configs 中监听的请求优先于拦截策略,不会被拦截
+type BlockPolicy struct {
+	// ResourceTypes 是要拦截的资源类型,例如 image, font, media, stylesheet
+	ResourceTypes []string `json:"resource_types,omitempty"`
+	// Domains 是要拦截的域名,同时匹配子域名,例如广告和统计服务的域名
+	Domains []string `json:"domains,omitempty"`
+	// DomainFiles 每行一个域名,# 开头的行为注释,与 Domains 合并使用
+	DomainFiles []string `json:"domain_files,omitempty"`
+	// URLPatterns 是要拦截的URL通配符模式,语法与 url_pattern 相同
+	URLPatterns []string `json:"url_patterns,omitempty"`
+}
+
+func (b *BlockPolicy) Validate() error {
+	for _, resourceType := range b.ResourceTypes {
+		if _, ok := ResourceTypeName(resourceType); !ok {
+			return fmt.Errorf("未知的资源类型: %s", resourceType)
+		}
+	}
+	for i, domain := range b.Domains {
+		if strings.TrimSpace(domain) == "" {
+			return fmt.Errorf("domains[%d]: 域名不能为空", i)
+		}
+	}
+	for i, pattern := range b.URLPatterns {
+		if pattern == "" {
+			return fmt.Errorf("url_patterns[%d]: URL模式不能为空", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package param
+
+import (
+	"fmt"
+)
+
+func init() {
+	RegisterAction("repeat", func() Action { return &RepeatAction{} })
+	RegisterAction("if_exists", func() Action { return &IfExistsAction{} })
+	RegisterAction("for_each_element", func() Action { return &ForEachElementAction{} })
+}
+
+// ActionContainer 由包含子操作的控制流操作实现
+type ActionContainer interface {
+	ChildActions() []ActionList
+}
+
+// WalkActions 深度优先遍历操作及其所有子操作
+func WalkActions(actions []Action, fn func(Action)) {
+	for _, action := range actions {
+		if action == nil {
+			continue
+		}
+		fn(action)
+		if container, ok := action.(ActionContainer); ok {
+			for _, children := range container.ChildActions() {
+				WalkActions(children, fn)
+			}
+		}
+	}
+}
+
+// JSONFlagCondition 检查监听到的最新响应中某个JSON字段的值
+type JSONFlagCondition struct {
+	// URLPattern 必须与 NetworkConfigs 中的某个 URL 模式完全一致
+	URLPattern string `json:"url_pattern"`
+	// Path 为点分隔的字段路径,如 zpData.hasMore,数组下标写作 list.0.id
+	Path string `json:"path"`
+}
+
+// RepeatAction 重复执行子操作,直到次数用尽或满足停止条件
+// 停止条件在每轮执行前检查
+type RepeatAction struct {
+	BaseParams
+	Actions ActionList `json:"actions"`
+	// Times 为最大执行轮数,指定停止条件时可以为 0,此时使用默认上限,
+	// 达到默认上限仍未满足停止条件时操作失败
+	Times int `json:"times"`
+	// UntilGone 不为空时,页面上不再匹配该选择器即停止
+	UntilGone string `json:"until_gone"`
+	// UntilFalse 不为空时,最新响应中该字段为 false 即停止
+	UntilFalse *JSONFlagCondition `json:"until_false"`
+}
+
+func (r *RepeatAction) Validate() error {
+	if len(r.Actions) == 0 {
+		return fmt.Errorf("重复操作必须指定子操作")
+	}
+	if r.Times < 0 {
+		return fmt.Errorf("重复操作的次数不能为负数")
+	}
+	if r.Times == 0 && r.UntilGone == "" && r.UntilFalse == nil {
+		return fmt.Errorf("重复操作必须指定次数或停止条件")
+	}
+	if r.UntilFalse != nil && (r.UntilFalse.URLPattern == "" || r.UntilFalse.Path == "") {
+		return fmt.Errorf("重复操作的 until_false 必须指定URL模式和字段路径")
+	}
+	return r.Actions.Validate()
+}
+
+func (r *RepeatAction) ChildActions() []ActionList {
+	return []ActionList{r.Actions}
+}
+
+// IfExistsAction 页面上存在匹配的元素时执行 Actions,否则执行 Else
+type IfExistsAction struct {
+	BaseParams
+	Selector string     `json:"selector"`
+	Actions  ActionList `json:"actions"`
+	Else     ActionList `json:"else"`
+}
+
+func (i *IfExistsAction) Validate() error {
+	if i.Selector == "" {
+		return fmt.Errorf("条件操作必须指定选择器")
+	}
+	if len(i.Actions) == 0 && len(i.Else) == 0 {
+		return fmt.Errorf("条件操作必须指定子操作")
+	}
+	if err := i.Actions.Validate(); err != nil {
+		return err
+	}
+	if err := i.Else.Validate(); err != nil {
+		return fmt.Errorf("else: %w", err)
+	}
+	return nil
+}
+
+func (i *IfExistsAction) ChildActions() []ActionList {
+	return []ActionList{i.Actions, i.Else}
+}
+
+// ForEachElementAction 对每个匹配的元素执行一遍子操作
+// 子操作中的选择器在该元素内部查找,选择器 :scope 表示元素本身
+type ForEachElementAction struct {
+	BaseParams
+	Selector string     `json:"selector"`
+	Actions  ActionList `json:"actions"`
+	// Limit 大于 0 时最多处理前 Limit 个元素
+	Limit int `json:"limit"`
+}
+
+func (f *ForEachElementAction) Validate() error {
+	if f.Selector == "" {
+		return fmt.Errorf("遍历操作必须指定选择器")
+	}
+	if len(f.Actions) == 0 {
+		return fmt.Errorf("遍历操作必须指定子操作")
+	}
+	if f.Limit < 0 {
+		return fmt.Errorf("遍历操作的数量上限不能为负数")
+	}
+	return f.Actions.Validate()
+}
+
+func (f *ForEachElementAction) ChildActions() []ActionList {
+	return []ActionList{f.Actions}
+}
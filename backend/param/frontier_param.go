@@ -0,0 +1,66 @@
+package param
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const (
+	DedupeMemory = "memory"
+	DedupeRedis  = "redis"
+)
+
+// FrontierParam 配置链接跟随: 处理器发现的链接经过规范化、过滤和去重后,
+// 以 Template 为模板生成新的 ParallelCrawlerParam
+type FrontierParam struct {
+	// Include 不为空时,URL 至少要匹配其中一个正则
+	Include []string `json:"include,omitempty"`
+	// Exclude 匹配任意一个正则的 URL 会被丢弃
+	Exclude []string `json:"exclude,omitempty"`
+	// MaxDepth 是跟随链接的最大深度,种子任务深度为 0
+	MaxDepth int `json:"max_depth"`
+	// MaxURLs 限制新发现URL的总数,为 0 时不限制
+	MaxURLs int `json:"max_urls,omitempty"`
+	// Dedupe 为去重集合的存储: memory(默认) 或 redis
+	// redis 集合在同名任务的多次运行间共享,最后一次写入后保留的时间和清空方式见 cmd/crawler 的 --frontier-ttl 和 --frontier-reset
+	Dedupe string `json:"dedupe,omitempty"`
+	// Template 的 URL 和 Depth 字段会被忽略,为空时新任务只导航不执行操作
+	Template *ParallelCrawlerParam `json:"template,omitempty"`
+}
+
+func (f *FrontierParam) Validate() error {
+	if f.MaxDepth <= 0 {
+		return fmt.Errorf("max_depth 必须大于0")
+	}
+	if f.MaxURLs < 0 {
+		return fmt.Errorf("max_urls 不能为负数")
+	}
+	switch f.Dedupe {
+	case "", DedupeMemory, DedupeRedis:
+	default:
+		return fmt.Errorf("未知的去重存储: %s", f.Dedupe)
+	}
+	for _, patterns := range [][]string{f.Include, f.Exclude} {
+		if _, err := CompilePatterns(patterns); err != nil {
+			return err
+		}
+	}
+	if f.Template != nil {
+		if err := f.Template.validateBody(); err != nil {
+			return fmt.Errorf("template: %w", err)
+		}
+	}
+	return nil
+}
+
+func CompilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	regs := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		reg, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("正则 %q 无效: %w", pattern, err)
+		}
+		regs = append(regs, reg)
+	}
+	return regs, nil
+}
@@ -0,0 +1,51 @@
+package param
+
+import (
+	"strings"
+
+	"github.com/go-rod/rod/lib/input"
+)
+
+// namedKeys 按键名称(不区分大小写)到 rod 按键的映射
+var namedKeys = map[string]input.Key{}
+
+// modifierKeys 修饰键名称(不区分大小写)到 rod 按键的映射
+var modifierKeys = map[string]input.Key{
+	"control": input.ControlLeft,
+	"ctrl":    input.ControlLeft,
+	"shift":   input.ShiftLeft,
+	"alt":     input.AltLeft,
+	"meta":    input.MetaLeft,
+}
+
+func init() {
+	keys := []input.Key{
+		input.Enter, input.Tab, input.Escape, input.Backspace, input.Delete, input.Space,
+		input.ArrowUp, input.ArrowDown, input.ArrowLeft, input.ArrowRight,
+		input.PageUp, input.PageDown, input.Home, input.End, input.Insert,
+		input.F1, input.F2, input.F3, input.F4, input.F5, input.F6,
+		input.F7, input.F8, input.F9, input.F10, input.F11, input.F12,
+	}
+	for _, key := range keys {
+		namedKeys[strings.ToLower(key.Info().Code)] = key
+	}
+	namedKeys["esc"] = input.Escape
+	for name, key := range modifierKeys {
+		namedKeys[name] = key
+	}
+}
+
+// LookupKey 解析按键名称,支持命名按键和单个可打印ASCII字符,名称不区分大小写
+func LookupKey(name string) (input.Key, bool) {
+	if len(name) == 1 && name[0] >= ' ' && name[0] <= '~' {
+		return input.Key(name[0]), true
+	}
+	key, ok := namedKeys[strings.ToLower(name)]
+	return key, ok
+}
+
+// LookupModifier 解析修饰键名称,只支持 Control(Ctrl)、Shift、Alt、Meta,名称不区分大小写
+func LookupModifier(name string) (input.Key, bool) {
+	key, ok := modifierKeys[strings.ToLower(name)]
+	return key, ok
+}
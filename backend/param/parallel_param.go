@@ -3,16 +3,140 @@ package param
 import (
 	"context"
 	"crawleragent-v2/types"
+	"fmt"
 )
 
+// ErrorPolicy 决定加载响应或执行 ProcessFunc 失败时的处理方式
+type ErrorPolicy string
+
+const (
+	// ErrorPolicyIgnore 只打印日志
+	ErrorPolicyIgnore ErrorPolicy = "ignore"
+	// ErrorPolicyCollect 记录到爬取报告中,任务继续执行,为默认策略
+	ErrorPolicyCollect ErrorPolicy = "collect"
+	// ErrorPolicyAbortTask 记录错误并终止当前任务
+	ErrorPolicyAbortTask ErrorPolicy = "abort_task"
+	// ErrorPolicyAbortRun 记录错误并终止整个爬取过程
+	ErrorPolicyAbortRun ErrorPolicy = "abort_run"
+)
+
+func (p ErrorPolicy) Validate() error {
+	switch p {
+	case "", ErrorPolicyIgnore, ErrorPolicyCollect, ErrorPolicyAbortTask, ErrorPolicyAbortRun:
+		return nil
+	default:
+		return fmt.Errorf("未知的错误策略: %s", p)
+	}
+}
+
 type ParallelNetworkConfig struct {
 	URLPattern string `json:"url_pattern"`
+	// Processor 是已注册处理器的名称,用于任务文件中替代 ProcessFunc
+	Processor string `json:"processor,omitempty"`
+	// OnError 为空时使用 ErrorPolicyCollect
+	OnError ErrorPolicy `json:"on_error,omitempty"`
 	//ToDocFunc   func(ctx context.Context, content types.UrlContent) ([]model.Document, error)
-	ProcessFunc func(ctx context.Context, content types.UrlContent) error
+	ProcessFunc func(ctx context.Context, content types.UrlContent) error `json:"-"`
 }
 
 type ParallelCrawlerParam struct {
+	// TaskID 为空时在执行时生成,队列任务使用队列中的ID
+	TaskID         string                   `json:"task_id,omitempty"`
 	URL            string                   `json:"url"`
 	NetworkConfigs []*ParallelNetworkConfig `json:"network_configs"`
-	Actions        []Action                 `json:"actions"`
+	Actions        ActionList               `json:"actions"`
+	// Depth 是任务在链接跟随中的深度,任务文件中的种子任务为 0
+	Depth int `json:"depth,omitempty"`
+	// IgnoreRobots 为 true 时不检查 robots.txt,用于已获得授权的网站
+	IgnoreRobots bool `json:"ignore_robots,omitempty"`
+	// Session 是会话文件路径,导航前将其中的 cookie 和 localStorage 注入页面
+	Session string `json:"session,omitempty"`
+	// Block 是请求拦截策略,为空时不拦截
+	Block *BlockPolicy `json:"block,omitempty"`
+	// Artifacts 为空时不保存截图等调试产物
+	Artifacts *ArtifactOptions `json:"artifacts,omitempty"`
+	// RateLimits 是访问该任务时生效的按host限流配置,随任务写入队列,覆盖 config.yaml 中同一host的配置
+	// 配置合并到整个浏览器池的限流器中,之后同一host的其他任务也按此配置限流
+	RateLimits []*HostLimit `json:"rate_limits,omitempty"`
+	// Humanize 为 true 时模拟真实用户的鼠标移动、滚动和输入节奏,降低被识别为爬虫的概率,但操作耗时更长
+	Humanize bool `json:"humanize,omitempty"`
+}
+
+type taskContextKey struct{}
+
+// ContextWithTask 将正在执行的任务附加到 ctx,处理器可以通过 TaskFromContext 获取
+func ContextWithTask(ctx context.Context, task *ParallelCrawlerParam) context.Context {
+	return context.WithValue(ctx, taskContextKey{}, task)
+}
+
+func TaskFromContext(ctx context.Context) (*ParallelCrawlerParam, bool) {
+	task, ok := ctx.Value(taskContextKey{}).(*ParallelCrawlerParam)
+	return task, ok
+}
+
+func (p *ParallelCrawlerParam) Validate() error {
+	if p.URL == "" {
+		return fmt.Errorf("必须指定URL")
+	}
+	if p.TaskID != "" {
+		if err := ValidateTaskID(p.TaskID); err != nil {
+			return fmt.Errorf("task_id: %w", err)
+		}
+	}
+	return p.validateBody()
+}
+
+// validateBody 校验除URL以外的字段,供任务模板复用
+func (p *ParallelCrawlerParam) validateBody() error {
+	for i, networkConfig := range p.NetworkConfigs {
+		if networkConfig == nil || networkConfig.URLPattern == "" {
+			return fmt.Errorf("network_configs[%d]: 必须指定URL模式", i)
+		}
+		if err := networkConfig.OnError.Validate(); err != nil {
+			return fmt.Errorf("network_configs[%d]: %w", i, err)
+		}
+	}
+	if p.Block != nil {
+		if err := p.Block.Validate(); err != nil {
+			return fmt.Errorf("block: %w", err)
+		}
+	}
+	for i, limit := range p.RateLimits {
+		if limit == nil {
+			return fmt.Errorf("rate_limits[%d]: 配置不能为空", i)
+		}
+		if err := limit.Validate(); err != nil {
+			return fmt.Errorf("rate_limits[%d]: %w", i, err)
+		}
+	}
+	if err := p.Actions.Validate(); err != nil {
+		return err
+	}
+	return p.validateURLPatterns()
+}
+
+// validateURLPatterns 检查操作引用的 url_pattern 是否在 network_configs 中,否则响应不会被记录,
+// 自动滚动只能依靠页面高度判断是否加载了新内容,重复操作的 until_false 永远无法满足
+func (p *ParallelCrawlerParam) validateURLPatterns() error {
+	patterns := make(map[string]bool, len(p.NetworkConfigs))
+	for _, networkConfig := range p.NetworkConfigs {
+		patterns[networkConfig.URLPattern] = true
+	}
+	var err error
+	WalkActions(p.Actions, func(action Action) {
+		if err != nil {
+			return
+		}
+		switch a := action.(type) {
+		case *AutoScrollAction:
+			if a.URLPattern != "" && !patterns[a.URLPattern] {
+				err = fmt.Errorf("auto_scroll: url_pattern %q 不在 network_configs 中", a.URLPattern)
+			}
+		case *RepeatAction:
+			if a.UntilFalse != nil && !patterns[a.UntilFalse.URLPattern] {
+				err = fmt.Errorf("repeat: until_false 的 url_pattern %q 不在 network_configs 中", a.UntilFalse.URLPattern)
+			}
+		}
+	})
+	return err
 }
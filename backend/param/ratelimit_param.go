@@ -0,0 +1,19 @@
+package param
+
+import "fmt"
+
+// HostLimit 是任务文件或任务中的按host限流配置,会覆盖 config.yaml 中同一host的配置
+type HostLimit struct {
+	// Host 同时匹配其子域名,为空表示默认配置
+	Host               string  `json:"host,omitempty"`
+	RequestsPerSecond  float64 `json:"requests_per_second,omitempty"`
+	Burst              int     `json:"burst,omitempty"`
+	MaxConcurrentPages int     `json:"max_concurrent_pages,omitempty"`
+}
+
+func (h *HostLimit) Validate() error {
+	if h.RequestsPerSecond < 0 || h.Burst < 0 || h.MaxConcurrentPages < 0 {
+		return fmt.Errorf("限流参数不能为负数")
+	}
+	return nil
+}
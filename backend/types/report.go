@@ -0,0 +1,104 @@
+package types
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// ActionStatus 是单个操作的执行结果
+type ActionStatus string
+
+const (
+	ActionSucceeded ActionStatus = "succeeded"
+	ActionFailed    ActionStatus = "failed"
+	// ActionIgnored 表示 optional 操作失败后被忽略,任务继续执行
+	ActionIgnored ActionStatus = "ignored"
+)
+
+// ActionOutcome 记录单个操作的执行情况
+type ActionOutcome struct {
+	// Path 是操作在任务中的位置,如 actions[2].rounds[0].actions[1]
+	Path     string        `json:"path"`
+	Type     string        `json:"type"`
+	Status   ActionStatus  `json:"status"`
+	Attempts int           `json:"attempts"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+	// Rounds 和 StopReason 由自动滚动操作记录,分别为实际执行的滚动轮数(包括最后没有新内容的一轮)和停止的原因
+	Rounds     int    `json:"rounds,omitempty"`
+	StopReason string `json:"stop_reason,omitempty"`
+}
+
+// TaskStatus 是单个爬取任务的最终状态
+type TaskStatus string
+
+const (
+	TaskSucceeded TaskStatus = "succeeded"
+	TaskFailed    TaskStatus = "failed"
+	// TaskBlocked 表示任务因 robots.txt 等策略被跳过,不计为失败
+	TaskBlocked TaskStatus = "blocked"
+	// TaskChallenged 表示页面出现了验证码等反爬验证且未能处理
+	TaskChallenged TaskStatus = "challenged"
+)
+
+// ChallengeRecord 记录任务中遇到的反爬验证及处理结果
+type ChallengeRecord struct {
+	// Kind 为触发检测的规则类型: selector / url / status
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"`
+	URL    string `json:"url"`
+	// Stage 为检测的时机: navigate 或操作的 Path
+	Stage  string    `json:"stage"`
+	Solved bool      `json:"solved"`
+	Error  string    `json:"error,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// ProcessError 记录监听到的响应在加载或处理时的错误
+type ProcessError struct {
+	URLPattern string `json:"url_pattern"`
+	URL        string `json:"url"`
+	// Stage 为出错的阶段: load(加载响应) 或 process(执行 ProcessFunc)
+	Stage string    `json:"stage"`
+	Error string    `json:"error"`
+	Time  time.Time `json:"time"`
+}
+
+// CrawlReport 是单个 ParallelCrawlerParam 的执行报告
+type CrawlReport struct {
+	// TaskID 是任务ID,队列任务为队列中的ID,也是产物目录的名称
+	TaskID string `json:"task_id,omitempty"`
+	URL    string `json:"url"`
+	// Depth 是链接跟随中的深度,种子任务为 0
+	Depth     int        `json:"depth,omitempty"`
+	WorkerID  int        `json:"worker_id"`
+	StartTime time.Time  `json:"start_time"`
+	EndTime   time.Time  `json:"end_time"`
+	Status    TaskStatus `json:"status"`
+	Error     string     `json:"error,omitempty"`
+	// Fingerprint 是任务使用的指纹名称
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// FinalURL 是任务结束时页面的URL,可能因跳转或翻页与 URL 不同
+	FinalURL string          `json:"final_url"`
+	Actions  []ActionOutcome `json:"actions"`
+	// Responses 是每个 URLPattern 捕获到的响应数量
+	Responses     map[string]int `json:"responses"`
+	ProcessErrors []ProcessError `json:"process_errors"`
+	// Challenges 是任务中遇到的反爬验证,包括已处理的
+	Challenges []ChallengeRecord `json:"challenges,omitempty"`
+	// ArtifactDir 和 Artifacts 是任务保存的截图、PDF和请求日志,没有开启时为空
+	ArtifactDir string   `json:"artifact_dir,omitempty"`
+	Artifacts   []string `json:"artifacts,omitempty"`
+}
+
+func (r *CrawlReport) Duration() time.Duration {
+	return r.EndTime.Sub(r.StartTime)
+}
+
+// WriteCrawlReports 以缩进的JSON格式导出报告
+func WriteCrawlReports(w io.Writer, reports []*CrawlReport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(reports)
+}